@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+func newBranchCmd(deps Deps) *cobra.Command {
+	var opts app.BranchOptions
+	var nul, create, interactive bool
+	var pick int
+	cmd := &cobra.Command{
+		Use:   "branch",
+		Short: "Suggest branch names for the staged (or else unstaged) changes, one per line",
+		Long: "Suggest kebab-case branch names for the staged changes, or for the\n" +
+			"unstaged changes to tracked files when nothing is staged.\n\n" +
+			"--prefix puts a type in front (\"feat\" gives feat/...), and --ticket, or a\n" +
+			"ticket key mentioned in --hint, follows it: feat/pay-12-add-login. Every\n" +
+			"name is checked with git check-ref-format.\n\n" +
+			"With --create (or --interactive on a terminal), the chosen name is created\n" +
+			"and checked out, carrying the local changes over.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			if !create && !interactive && cmd.Flags().Changed("pick") {
+				return errors.New("--pick requires --create or --interactive")
+			}
+
+			uc, err := deps.NewBranchUC()
+			if err != nil {
+				return err
+			}
+			res, err := uc.Execute(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if res.NoChanges {
+				cmd.Println("No changes to name a branch after.")
+				return nil
+			}
+			if !create && !(interactive && terminalOutput(cmd)) {
+				printSuggestions(cmd, res.Suggestions, false, nul)
+				return nil
+			}
+
+			chosen, err := chooseSuggestion(cmd, res.Suggestions, pick,
+				"Branch name", regenerateBranch(cmd.Context(), uc, opts))
+			if errors.Is(err, errCancelled) {
+				cmd.PrintErrln("No branch created.")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := uc.Create(cmd.Context(), chosen.String()); err != nil {
+				return err
+			}
+			cmd.Printf("Switched to a new branch '%s'\n", chosen)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.Prefix, "prefix", "",
+		"prefix every name, e.g. feat or fix")
+	cmd.Flags().StringVar(&opts.Ticket, "ticket", "",
+		"ticket key to put after the prefix, e.g. PAY-12")
+	cmd.Flags().StringVar(&opts.Hint, "hint", "",
+		"extra guidance for the names; a ticket key in it is used as --ticket")
+	cmd.Flags().BoolVarP(&nul, "null", "z", false,
+		"terminate each suggestion with NUL instead of a newline")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false,
+		"choose, edit, or regenerate in a terminal picker, "+
+			"then create the branch")
+	cmd.Flags().BoolVar(&create, "create", false,
+		"create and switch to a suggested branch")
+	cmd.Flags().IntVar(&pick, "pick", 0,
+		"with --create, use the Nth suggestion instead of asking "+
+			"(1 is the first)")
+	return cmd
+}
+
+func regenerateBranch(
+	ctx context.Context,
+	uc *app.SuggestBranchNames,
+	opts app.BranchOptions,
+) regenerateFunc {
+	return func(hint string, avoid []string) ([]domain.Suggestion, error) {
+		// Keep the original hint: it may carry the ticket key.
+		opts.Hint = strings.TrimSpace(opts.Hint + " " + hint)
+		opts.Avoid = avoid
+		res, err := uc.Execute(ctx, opts)
+		return res.Suggestions, err
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+func newBumpCmd(deps Deps) *cobra.Command {
+	var tag bool
+	cmd := &cobra.Command{
+		Use:   "bump",
+		Short: "Recommend the next semantic version from the commits since the latest release tag",
+		Long: "Recommend the next semantic version from the commits since the latest release\n" +
+			"tag (vX.Y.Z or X.Y.Z). Conventional commit headers decide deterministically:\n" +
+			"breaking changes are major, feat is minor, fix and perf are patch. Other\n" +
+			"messages are classified by the model from the message and diff.\n\n" +
+			"The version goes to stdout and the justification to stderr. With --tag, an\n" +
+			"annotated tag is created at HEAD with the release notes as its message.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+			uc, err := deps.NewBumpUC()
+			if err != nil {
+				return err
+			}
+			plan, err := uc.Plan(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			current := plan.Current
+			if current == "" {
+				current = "none (starting from 0.0.0)"
+			}
+			cmd.PrintErrf("Latest release: %s\n", current)
+			for _, c := range plan.Commits {
+				reason := c.Reason
+				if c.FromModel {
+					reason = "model: " + reason
+				}
+				subject, _, _ := strings.Cut(c.Commit.Message, "\n")
+				cmd.PrintErrf("  %-5s  %s  %s  (%s)\n",
+					c.Impact, c.Commit.ShortHash, subject, reason)
+			}
+			if plan.Impact == domain.ImpactNone {
+				cmd.PrintErrln("No release needed.")
+				return nil
+			}
+			cmd.PrintErrf("Recommended %s bump.\n", plan.Impact)
+			cmd.Println(plan.Next.String())
+
+			if !tag {
+				return nil
+			}
+			name, err := uc.Tag(cmd.Context(), plan,
+				time.Now().Format(time.DateOnly))
+			if err != nil {
+				return err
+			}
+			cmd.PrintErrf("Created tag %s.\n", name)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&tag, "tag", false,
+		"create the recommended version as an annotated tag at HEAD")
+	return cmd
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+func newChangelogCmd(deps Deps) *cobra.Command {
+	var opts app.ChangelogOptions
+	var prepend string
+	cmd := &cobra.Command{
+		Use:   "changelog <from>..<to>",
+		Short: "Write Keep a Changelog release notes for a commit range",
+		Long: "Write release notes for the commits in from..to (to defaults to HEAD), grouped\n" +
+			"by conventional type into Breaking Changes, Added, Fixed, and Changed. Docs,\n" +
+			"tests, and chores are left out unless they are breaking; messages that are\n" +
+			"not conventional count as changes.\n\n" +
+			"The markdown goes to stdout, or with --prepend above the newest release in a\n" +
+			"CHANGELOG.md, below any Unreleased section.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			if opts.Version != "" && opts.Date == "" {
+				opts.Date = time.Now().Format(time.DateOnly)
+			}
+
+			uc, err := deps.NewChangelogUC()
+			if err != nil {
+				return err
+			}
+			res, err := uc.Execute(cmd.Context(), args[0], opts)
+			if err != nil {
+				return err
+			}
+			if res.NoChanges {
+				cmd.PrintErrf("No changes worth a changelog entry in %s.\n", args[0])
+				return nil
+			}
+			if prepend == "" {
+				cmd.Print(res.Markdown)
+				return nil
+			}
+
+			existing, err := os.ReadFile(prepend)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			updated := domain.PrependRelease(string(existing), res.Markdown)
+			if err := os.WriteFile(prepend, []byte(updated), 0o644); err != nil {
+				return err
+			}
+			cmd.PrintErrf("Updated %s.\n", prepend)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.Version, "release", "",
+		"version to head the notes with, instead of Unreleased")
+	cmd.Flags().StringVar(&opts.Date, "date", "",
+		"release date (default today, with --release)")
+	cmd.Flags().StringVar(&prepend, "prepend", "",
+		"insert the notes into this changelog file, e.g. CHANGELOG.md")
+	return cmd
+}
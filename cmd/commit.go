@@ -1,22 +1,51 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+
 	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+	"github.com/m7medvision/lazycommit/internal/domain"
 )
 
 func newCommitCmd(deps Deps) *cobra.Command {
-	return &cobra.Command{
+	var opts app.CommitOptions
+	var flags app.CommitFlags
+	var nul, apply, interactive bool
+	var pick int
+	cmd := &cobra.Command{
 		Use:   "commit",
 		Short: "Suggest commit messages for the staged diff, one per line",
-		Args:  cobra.NoArgs,
+		Long: "Suggest commit messages for the staged diff, one per line.\n\n" +
+			"With --body, each suggestion is a full message with a wrapped body and\n" +
+			"optional footers; messages are separated by this line:\n\n" +
+			"    " + domain.MessageSeparator + "\n\n" +
+			"With --interactive, the suggestions open in a terminal picker and the\n" +
+			"chosen one is committed; when stdout is not a terminal the plain list\n" +
+			"is printed instead.\n\n" +
+			"With --apply, one suggestion is committed: the one given by --pick, or\n" +
+			"the one chosen in the picker (or at a numbered prompt without a terminal).",
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			cmd.SilenceUsage = true
+			if !apply && !interactive {
+				for _, name := range []string{
+					"pick", "edit", "no-verify", "sign", "signoff",
+				} {
+					if cmd.Flags().Changed(name) {
+						return errors.New("--" + name +
+							" requires --apply or --interactive")
+					}
+				}
+			}
 
 			uc, err := deps.NewCommitUC()
 			if err != nil {
 				return err
 			}
-			res, err := uc.Execute(cmd.Context())
+			res, err := uc.Execute(cmd.Context(), opts)
 			if err != nil {
 				return err
 			}
@@ -24,10 +53,65 @@ func newCommitCmd(deps Deps) *cobra.Command {
 				cmd.Println("No staged changes to commit.")
 				return nil
 			}
-			for _, s := range res.Suggestions {
-				cmd.Println(s.String())
+			if !apply && !(interactive && terminalOutput(cmd)) {
+				printSuggestions(cmd, res.Suggestions, opts.Body, nul)
+				return nil
+			}
+
+			chosen, err := chooseSuggestion(cmd, res.Suggestions, pick,
+				"Commit message", regenerateCommit(cmd.Context(), uc, opts))
+			if errors.Is(err, errCancelled) {
+				cmd.PrintErrln("No commit created.")
+				return nil
+			}
+			if err != nil {
+				return err
 			}
+			writer, err := deps.NewCreateCommitUC()
+			if err != nil {
+				return err
+			}
+			hash, err := writer.Execute(cmd.Context(), chosen, flags)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("[%s] %s\n", hash, chosen.Subject())
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&opts.Body, "body", false,
+		"generate full messages with a body and footers")
+	cmd.Flags().BoolVarP(&nul, "null", "z", false,
+		"terminate each suggestion with NUL instead of a newline")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false,
+		"choose, edit, or regenerate in a terminal picker, then commit")
+	cmd.Flags().BoolVar(&apply, "apply", false,
+		"commit the staged changes with a suggestion")
+	cmd.Flags().IntVar(&pick, "pick", 0,
+		"with --apply, commit the Nth suggestion instead of asking "+
+			"(1 is the first)")
+	cmd.Flags().BoolVarP(&flags.Edit, "edit", "e", false,
+		"with --apply, edit the message in $EDITOR before committing")
+	cmd.Flags().BoolVarP(&flags.NoVerify, "no-verify", "n", false,
+		"with --apply, skip the pre-commit and commit-msg hooks")
+	cmd.Flags().BoolVarP(&flags.Sign, "sign", "S", false,
+		"with --apply, sign the commit")
+	cmd.Flags().BoolVarP(&flags.SignOff, "signoff", "s", false,
+		"with --apply, add a Signed-off-by trailer")
+	return cmd
+}
+
+func regenerateCommit(
+	ctx context.Context,
+	uc *app.GenerateCommitSuggestions,
+	opts app.CommitOptions,
+) regenerateFunc {
+	return func(hint string, avoid []string) ([]domain.Suggestion, error) {
+		opts.Hint, opts.Avoid = hint, avoid
+		res, err := uc.Execute(ctx, opts)
+		if err == nil && res.NoChanges {
+			err = errors.New("no staged changes")
+		}
+		return res.Suggestions, err
+	}
 }
@@ -2,9 +2,12 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -49,6 +52,21 @@ func newConfigGetCmd(deps Deps) *cobra.Command {
 			if settings.APIKey != "" {
 				cmd.Printf("api_key:  %s\n", maskSecret(settings.APIKey))
 			}
+			if len(settings.Command) > 0 {
+				cmd.Printf("command:  %s\n", strings.Join(settings.Command, " "))
+			}
+			if settings.APIVersion != "" {
+				cmd.Printf("api_version: %s\n", settings.APIVersion)
+			}
+			if settings.NumCtx > 0 {
+				cmd.Printf("num_ctx:  %d\n", settings.NumCtx)
+			}
+			if settings.KeepAlive != "" {
+				cmd.Printf("keep_alive: %s\n", settings.KeepAlive)
+			}
+			if budget := settings.DiffTokenBudget(); budget > 0 {
+				cmd.Printf("max_diff_tokens: %d\n", budget)
+			}
 			cmd.Printf("language: %s\n", prompts.Language)
 			cmd.Printf("count:    %d\n", prompts.SuggestionCount)
 			return nil
@@ -77,12 +95,65 @@ func newConfigSetCmd(deps Deps) *cobra.Command {
 			backends.Active = active
 
 			settings := backends.Backends[active]
-			settings.Model = ask(cmd, in, fmt.Sprintf("Model [%s]: ", orNone(settings.Model)), settings.Model)
-			if active == "openai-compatible" {
+			switch active {
+			case "ollama":
+				settings.BaseURL = askBaseURL(cmd, in,
+					"localhost:11434", settings.BaseURL)
+			case "azure-openai":
 				settings.BaseURL = ask(cmd, in,
-					fmt.Sprintf("Base URL (empty for official OpenAI) [%s]: ", orNone(settings.BaseURL)), settings.BaseURL)
-				settings.APIKey = ask(cmd, in,
-					fmt.Sprintf("API key, plain or $ENV_VAR [%s]: ", maskSecret(settings.APIKey)), settings.APIKey)
+					fmt.Sprintf("Resource endpoint, "+
+						"https://<resource>.openai.azure.com [%s]: ",
+						orNone(settings.BaseURL)),
+					settings.BaseURL)
+			}
+			settings.Model = chooseModel(cmd, in, deps, active, settings)
+			switch active {
+			case "openai-compatible":
+				settings.BaseURL = askBaseURL(cmd, in,
+					"official OpenAI", settings.BaseURL)
+				settings.APIKey = askAPIKey(cmd, in, settings.APIKey)
+			case "anthropic":
+				settings.BaseURL = askBaseURL(cmd, in,
+					"api.anthropic.com", settings.BaseURL)
+				settings.APIKey = askAPIKey(cmd, in, settings.APIKey)
+			case "azure-openai":
+				settings.APIVersion = ask(cmd, in,
+					fmt.Sprintf("API version (empty for the built-in default) [%s]: ",
+						orNone(settings.APIVersion)),
+					settings.APIVersion)
+				settings.APIKey = askAPIKey(cmd, in, settings.APIKey)
+			case "exec":
+				command := strings.Join(settings.Command, " ")
+				line := ask(cmd, in,
+					fmt.Sprintf("Command, space-separated, "+
+						"{model} for the model [%s]: ", orNone(command)),
+					command)
+				settings.Command = strings.Fields(line)
+				settings.InputFormat = ask(cmd, in,
+					fmt.Sprintf("Prompt on stdin as json or text [%s]: ",
+						orNone(settings.InputFormat)),
+					settings.InputFormat)
+			case "gemini":
+				settings.BaseURL = askBaseURL(cmd, in,
+					"generativelanguage.googleapis.com/v1beta",
+					settings.BaseURL)
+				settings.APIKey = askAPIKey(cmd, in, settings.APIKey)
+			case "ollama":
+				settings.NumCtx, err = askInt(cmd, in,
+					"Context length, num_ctx (0 for the model default)",
+					settings.NumCtx)
+				if err != nil {
+					return err
+				}
+				settings.KeepAlive = ask(cmd, in,
+					fmt.Sprintf("Keep loaded for, keep_alive such as 30m "+
+						"or -1 (empty for Ollama's default) [%s]: ",
+						orNone(settings.KeepAlive)),
+					settings.KeepAlive)
+				if !validKeepAlive(settings.KeepAlive) {
+					return fmt.Errorf("keep_alive: want a duration such as "+
+						"30m or a number of seconds, got %q", settings.KeepAlive)
+				}
 			}
 			if backends.Backends == nil {
 				backends.Backends = map[string]config.BackendSettings{}
@@ -113,6 +184,10 @@ func newConfigSetCmd(deps Deps) *cobra.Command {
 }
 
 func chooseBackend(cmd *cobra.Command, in *bufio.Scanner, names []string, current string) (string, error) {
+	// The default backend stays number 1 however many others are added.
+	if i := slices.Index(names, config.DefaultBackend); i > 0 {
+		names = slices.Concat([]string{config.DefaultBackend}, names[:i], names[i+1:])
+	}
 	cmd.Println("Backends:")
 	for i, name := range names {
 		marker := " "
@@ -137,6 +212,100 @@ func chooseBackend(cmd *cobra.Command, in *bufio.Scanner, names []string, curren
 	return "", fmt.Errorf("unknown backend %q (available: %s)", answer, strings.Join(names, ", "))
 }
 
+// listModelsTimeout bounds the model listing, so an unreachable host only
+// delays config set.
+const listModelsTimeout = 5 * time.Second
+
+// chooseModel offers the backend's own models as a numbered list when it can
+// list them; otherwise, or for a name not in the list, the reply is taken
+// as typed.
+func chooseModel(
+	cmd *cobra.Command,
+	in *bufio.Scanner,
+	deps Deps,
+	backend string,
+	settings config.BackendSettings,
+) string {
+	var models []string
+	if deps.ListModels != nil {
+		ctx, cancel := context.WithTimeout(cmd.Context(), listModelsTimeout)
+		defer cancel()
+		var err error
+		models, err = deps.ListModels(ctx, backend, settings.BaseURL)
+		if err != nil {
+			cmd.PrintErrf("Could not list models, "+
+				"type the name instead: %v\n", err)
+		}
+	}
+	if len(models) > 0 {
+		cmd.Println("Models:")
+		for i, name := range models {
+			marker := " "
+			if name == settings.Model {
+				marker = "*"
+			}
+			cmd.Printf("  %d) %s %s\n", i+1, marker, name)
+		}
+	}
+	label := "Model"
+	if backend == "azure-openai" {
+		label = "Deployment name"
+	}
+	answer := ask(cmd, in,
+		fmt.Sprintf("%s [%s]: ", label, orNone(settings.Model)),
+		settings.Model)
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(models) {
+		return models[n-1]
+	}
+	return answer
+}
+
+// askBaseURL asks for an endpoint; empty keeps the backend's default,
+// named by defaultName.
+func askBaseURL(
+	cmd *cobra.Command,
+	in *bufio.Scanner,
+	defaultName, current string,
+) string {
+	return ask(cmd, in,
+		fmt.Sprintf("Base URL (empty for %s) [%s]: ",
+			defaultName, orNone(current)),
+		current)
+}
+
+func askAPIKey(cmd *cobra.Command, in *bufio.Scanner, current string) string {
+	return ask(cmd, in,
+		fmt.Sprintf("API key, plain or $ENV_VAR [%s]: ", maskSecret(current)),
+		current)
+}
+
+// validKeepAlive accepts what Ollama does for keep_alive: a duration or a
+// number of seconds, negative for keeping the model loaded.
+func validKeepAlive(s string) bool {
+	if _, err := strconv.Atoi(s); s == "" || err == nil {
+		return true
+	}
+	_, err := time.ParseDuration(s)
+	return err == nil
+}
+
+// askInt asks for a non-negative number, keeping current on an empty reply.
+func askInt(
+	cmd *cobra.Command,
+	in *bufio.Scanner,
+	label string,
+	current int,
+) (int, error) {
+	answer := ask(cmd, in,
+		fmt.Sprintf("%s [%d]: ", label, current), strconv.Itoa(current))
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s: want a non-negative number, got %q",
+			label, answer)
+	}
+	return n, nil
+}
+
 // ask prompts and returns the trimmed reply, or fallback when the reply is
 // empty or stdin is closed.
 func ask(cmd *cobra.Command, in *bufio.Scanner, prompt, fallback string) string {
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+func newHookCmd(deps Deps) *cobra.Command {
+	root := &cobra.Command{
+		Use:   "hook",
+		Short: "Draft messages for plain `git commit` with a prepare-commit-msg hook",
+	}
+	root.AddCommand(
+		newHookInstallCmd(deps),
+		newHookUninstallCmd(deps),
+		newHookStatusCmd(deps),
+		newHookRunCmd(deps),
+	)
+	return root
+}
+
+func newHookInstallCmd(deps Deps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "install",
+		Short: "Install the hook, chaining any existing prepare-commit-msg hook",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+
+			uc, err := deps.NewHookUC()
+			if err != nil {
+				return err
+			}
+			status, err := uc.Install(cmd.Context())
+			if err != nil {
+				return err
+			}
+			cmd.Printf("Installed %s\n", status.Path)
+			if status.Chained {
+				cmd.Printf("The existing hook runs first: %s\n",
+					status.Path+domain.ChainedHookSuffix)
+			}
+			return nil
+		},
+	}
+}
+
+func newHookUninstallCmd(deps Deps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the hook and restore a chained one",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+
+			uc, err := deps.NewHookUC()
+			if err != nil {
+				return err
+			}
+			status, err := uc.Uninstall(cmd.Context())
+			if err != nil {
+				return err
+			}
+			cmd.Printf("Removed %s\n", status.Path)
+			if status.Foreign {
+				cmd.Println("The previous hook was restored.")
+			}
+			return nil
+		},
+	}
+}
+
+func newHookStatusCmd(deps Deps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the hook is installed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cmd.SilenceUsage = true
+
+			uc, err := deps.NewHookUC()
+			if err != nil {
+				return err
+			}
+			status, err := uc.Status(cmd.Context())
+			if err != nil {
+				return err
+			}
+			printHookStatus(cmd, status)
+			return nil
+		},
+	}
+}
+
+func printHookStatus(cmd *cobra.Command, status app.HookStatus) {
+	switch {
+	case status.Installed && status.Chained:
+		cmd.Printf("installed: %s (chains %s)\n",
+			status.Path, status.Path+domain.ChainedHookSuffix)
+	case status.Installed:
+		cmd.Printf("installed: %s\n", status.Path)
+	case status.Foreign:
+		cmd.Printf("not installed: another hook is at %s; "+
+			"install chains it\n", status.Path)
+	default:
+		cmd.Printf("not installed: %s\n", status.Path)
+	}
+}
+
+func newHookRunCmd(deps Deps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "run <msgfile> [<source> [<sha>]]",
+		Short: "Fill a commit message file; called by the installed hook",
+		Long: "Fill a commit message file; called by the installed hook with git's\n" +
+			"prepare-commit-msg arguments. The file is only filled when <source> is\n" +
+			"empty, i.e. for a plain `git commit`: the top suggestion becomes the\n" +
+			"message and the others are added as comments.",
+		Args: cobra.RangeArgs(1, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			source := ""
+			if len(args) > 1 {
+				source = args[1]
+			}
+			current, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			uc, err := deps.NewPrepareMessageUC()
+			if err != nil {
+				return err
+			}
+			message, changed, err := uc.Execute(cmd.Context(), source, string(current))
+			if err != nil || !changed {
+				return err
+			}
+			return os.WriteFile(args[0], []byte(message), 0o644)
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+	"github.com/m7medvision/lazycommit/internal/tui"
+)
+
+// errCancelled reports that the user left the picker without choosing.
+var errCancelled = errors.New("cancelled")
+
+// regenerateFunc re-runs generation with a user hint, avoiding earlier
+// suggestions when asked for more.
+type regenerateFunc func(hint string, avoid []string) ([]domain.Suggestion, error)
+
+// terminalOutput reports whether the command writes to a terminal, the
+// condition for the interactive picker; anything else gets plain output.
+func terminalOutput(cmd *cobra.Command) bool {
+	f, ok := cmd.OutOrStdout().(*os.File)
+	return ok && tui.IsTerminal(f)
+}
+
+// chooseSuggestion picks by --pick index, in the terminal picker when
+// stdout is a terminal, or at a numbered prompt otherwise.
+func chooseSuggestion(
+	cmd *cobra.Command,
+	suggestions []domain.Suggestion,
+	pick int,
+	title string,
+	regenerate regenerateFunc,
+) (domain.Suggestion, error) {
+	if pick == 0 && terminalOutput(cmd) {
+		return pickInteractively(title, suggestions, regenerate)
+	}
+	return pickSuggestion(cmd, suggestions, pick)
+}
+
+// pickInteractively shows suggestions in the terminal picker. The chosen
+// text, possibly edited, is validated as a message again.
+func pickInteractively(
+	title string,
+	suggestions []domain.Suggestion,
+	regenerate regenerateFunc,
+) (domain.Suggestion, error) {
+	term, err := tui.OpenTerminal()
+	if err != nil {
+		return domain.Suggestion{}, err
+	}
+	defer term.Close()
+
+	var hint string
+	picker := &tui.Picker{
+		Title: title,
+		Items: texts(suggestions),
+		Width: term.Width,
+		Regenerate: func(h string) ([]string, error) {
+			hint = h
+			s, err := regenerate(hint, nil)
+			return texts(s), err
+		},
+		More: func(current []string) ([]string, error) {
+			s, err := regenerate(hint, current)
+			return texts(s), err
+		},
+	}
+	choice, ok, err := picker.Run(term, term)
+	if err != nil {
+		return domain.Suggestion{}, err
+	}
+	if !ok {
+		return domain.Suggestion{}, errCancelled
+	}
+	return domain.NewMessage(choice)
+}
+
+func texts(suggestions []domain.Suggestion) []string {
+	out := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		out[i] = s.String()
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// printSuggestions writes one suggestion per line. Multi-line messages are
+// separated by a domain.MessageSeparator line instead; with nul, every
+// suggestion is NUL-terminated as-is, for pickers such as `fzf --read0`.
+func printSuggestions(
+	cmd *cobra.Command,
+	suggestions []domain.Suggestion,
+	multiline, nul bool,
+) {
+	for i, s := range suggestions {
+		switch {
+		case nul:
+			cmd.Print(s.String() + "\x00")
+		case multiline && i > 0:
+			cmd.Println(domain.MessageSeparator)
+			fallthrough
+		default:
+			cmd.Println(s.String())
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// pickSuggestion returns the index-th suggestion (1-based), or asks on
+// stdin when index is zero. The numbered list and prompt go to stderr so
+// stdout only carries the result.
+func pickSuggestion(
+	cmd *cobra.Command,
+	suggestions []domain.Suggestion,
+	index int,
+) (domain.Suggestion, error) {
+	if index < 0 || index > len(suggestions) {
+		return domain.Suggestion{}, fmt.Errorf(
+			"--pick %d is out of range (1-%d)", index, len(suggestions))
+	}
+	if index > 0 {
+		return suggestions[index-1], nil
+	}
+
+	out := cmd.ErrOrStderr()
+	for i, s := range suggestions {
+		text := strings.ReplaceAll(s.String(), "\n", "\n    ")
+		_, _ = fmt.Fprintf(out, "%2d) %s\n", i+1, text)
+	}
+	_, _ = fmt.Fprintf(out, "Pick a suggestion [1-%d, default 1]: ", len(suggestions))
+
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" {
+		if err != nil {
+			return domain.Suggestion{}, errors.New("no suggestion picked")
+		}
+		return suggestions[0], nil
+	}
+	n, convErr := strconv.Atoi(line)
+	if convErr != nil || n < 1 || n > len(suggestions) {
+		return domain.Suggestion{}, fmt.Errorf("invalid choice %q", line)
+	}
+	return suggestions[n-1], nil
+}
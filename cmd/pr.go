@@ -1,22 +1,37 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+
 	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+	"github.com/m7medvision/lazycommit/internal/domain"
 )
 
 func newPRCmd(deps Deps) *cobra.Command {
-	return &cobra.Command{
+	var nul, interactive, body bool
+	cmd := &cobra.Command{
 		Use:   "pr <target-branch>",
 		Short: "Suggest pull request titles against a target branch, one per line",
-		Args:  cobra.ExactArgs(1),
+		Long: "Suggest pull request titles against a target branch, one per line.\n\n" +
+			"With --body, write a full markdown description instead, from the branch's\n" +
+			"commits and diff. A repository pull request template such as\n" +
+			".github/pull_request_template.md sets its sections.",
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.SilenceUsage = true
+			if body {
+				return printPRDescription(cmd, deps, args[0])
+			}
 
 			uc, err := deps.NewPRUC()
 			if err != nil {
 				return err
 			}
-			res, err := uc.Execute(cmd.Context(), args[0])
+			res, err := uc.Execute(cmd.Context(), args[0], app.PRTitleOptions{})
 			if err != nil {
 				return err
 			}
@@ -24,10 +39,63 @@ func newPRCmd(deps Deps) *cobra.Command {
 				cmd.Printf("No changes against %s.\n", args[0])
 				return nil
 			}
-			for _, s := range res.Suggestions {
-				cmd.Println(s.String())
+			if !interactive || !terminalOutput(cmd) {
+				printSuggestions(cmd, res.Suggestions, false, nul)
+				return nil
+			}
+
+			chosen, err := pickInteractively("Pull request title",
+				res.Suggestions, regeneratePR(cmd.Context(), uc, args[0]))
+			if errors.Is(err, errCancelled) {
+				return nil
+			}
+			if err != nil {
+				return err
 			}
+			cmd.Println(chosen.String())
 			return nil
 		},
 	}
+	cmd.Flags().BoolVarP(&nul, "null", "z", false,
+		"terminate each suggestion with NUL instead of a newline")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false,
+		"choose, edit, or regenerate in a terminal picker, "+
+			"then print the choice")
+	cmd.Flags().BoolVar(&body, "body", false,
+		"write a full markdown pull request description instead of titles")
+	cmd.MarkFlagsMutuallyExclusive("body", "interactive")
+	cmd.MarkFlagsMutuallyExclusive("body", "null")
+	return cmd
+}
+
+func printPRDescription(cmd *cobra.Command, deps Deps, target string) error {
+	uc, err := deps.NewPRDescriptionUC()
+	if err != nil {
+		return err
+	}
+	res, err := uc.Execute(cmd.Context(), target, app.PRTitleOptions{})
+	if err != nil {
+		return err
+	}
+	if res.NoChanges {
+		cmd.Printf("No changes against %s.\n", target)
+		return nil
+	}
+	cmd.Println(res.Suggestions[0].String())
+	return nil
+}
+
+func regeneratePR(
+	ctx context.Context,
+	uc *app.GeneratePRTitles,
+	target string,
+) regenerateFunc {
+	return func(hint string, avoid []string) ([]domain.Suggestion, error) {
+		res, err := uc.Execute(ctx, target,
+			app.PRTitleOptions{Hint: hint, Avoid: avoid})
+		if err == nil && res.NoChanges {
+			err = fmt.Errorf("no changes against %s", target)
+		}
+		return res.Suggestions, err
+	}
 }
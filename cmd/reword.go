@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+func newRewordCmd(deps Deps) *cobra.Command {
+	var opts app.CommitOptions
+	var nul, apply, interactive bool
+	var pick int
+	cmd := &cobra.Command{
+		Use:   "reword <commit>",
+		Short: "Suggest a better message for an existing commit, and optionally apply it",
+		Long: "Suggest a better message for an existing commit from the diff it introduced.\n\n" +
+			"With --apply (or --interactive on a terminal), the chosen message replaces\n" +
+			"the commit's: HEAD is amended, and an older commit on the current branch is\n" +
+			"rewritten together with the commits after it, keeping their contents.\n" +
+			"Commits that are already on a remote-tracking branch are refused.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			rev := args[0]
+			if !apply && !interactive && cmd.Flags().Changed("pick") {
+				return errors.New("--pick requires --apply or --interactive")
+			}
+
+			uc, err := deps.NewRewordUC()
+			if err != nil {
+				return err
+			}
+			rewrite := apply || (interactive && terminalOutput(cmd))
+			if rewrite {
+				if err := uc.CheckRewritable(cmd.Context(), rev); err != nil {
+					return err
+				}
+			}
+			res, err := uc.Suggest(cmd.Context(), rev, opts)
+			if err != nil {
+				return err
+			}
+			if res.NoChanges {
+				cmd.Printf("Commit %s has no changes to describe.\n", rev)
+				return nil
+			}
+			if !rewrite {
+				printSuggestions(cmd, res.Suggestions, opts.Body, nul)
+				return nil
+			}
+
+			chosen, err := chooseSuggestion(cmd, res.Suggestions, pick,
+				"New message for "+rev,
+				regenerateReword(cmd.Context(), uc, rev, opts))
+			if errors.Is(err, errCancelled) {
+				cmd.PrintErrln("Commit left unchanged.")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			hash, err := uc.Apply(cmd.Context(), rev, chosen)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("[%s] %s\n", hash, chosen.Subject())
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&opts.Body, "body", false,
+		"generate full messages with a body and footers")
+	cmd.Flags().BoolVarP(&nul, "null", "z", false,
+		"terminate each suggestion with NUL instead of a newline")
+	cmd.Flags().BoolVarP(&interactive, "interactive", "i", false,
+		"choose, edit, or regenerate in a terminal picker, then reword")
+	cmd.Flags().BoolVar(&apply, "apply", false,
+		"replace the commit's message with a suggestion")
+	cmd.Flags().IntVar(&pick, "pick", 0,
+		"with --apply, use the Nth suggestion instead of asking "+
+			"(1 is the first)")
+	return cmd
+}
+
+func regenerateReword(
+	ctx context.Context,
+	uc *app.RewordCommit,
+	rev string,
+	opts app.CommitOptions,
+) regenerateFunc {
+	return func(hint string, avoid []string) ([]domain.Suggestion, error) {
+		opts.Hint, opts.Avoid = hint, avoid
+		res, err := uc.Suggest(ctx, rev, opts)
+		return res.Suggestions, err
+	}
+}
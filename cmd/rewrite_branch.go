@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bufio"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+)
+
+func newRewriteBranchCmd(deps Deps) *cobra.Command {
+	var opts app.CommitOptions
+	var jobs int
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "rewrite-branch <base>",
+		Short: "Regenerate the message of every commit since base and rewrite the branch",
+		Long: "Generate a new message for each commit on the current branch since base,\n" +
+			"show the plan, and on confirmation rewrite the branch in one pass. Trees,\n" +
+			"authors, and merge commits are kept. The old branch tip is saved under\n" +
+			"refs/lazycommit/backup/<branch> so the rewrite can be undone.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			uc, err := deps.NewRewriteBranchUC()
+			if err != nil {
+				return err
+			}
+			plan, err := uc.Plan(cmd.Context(), args[0], opts, jobs)
+			if err != nil {
+				return err
+			}
+			if plan.Rewritten() == 0 {
+				cmd.Printf("No commits to rewrite since %s.\n", args[0])
+				return nil
+			}
+
+			for _, e := range plan.Entries {
+				switch {
+				case e.Commit.Merge:
+					cmd.Printf("%s  %s (merge, unchanged)\n",
+						e.Commit.ShortHash, e.Commit.Subject)
+					continue
+				case !e.Changed():
+					cmd.Printf("%s  %s (no changes, unchanged)\n",
+						e.Commit.ShortHash, e.Commit.Subject)
+					continue
+				}
+				cmd.Printf("%s  %s\n    -> %s\n",
+					e.Commit.ShortHash, e.Commit.Subject, e.Message.Subject())
+			}
+			remote, err := uc.PublishedIn(cmd.Context(), plan)
+			if err != nil {
+				return err
+			}
+			if remote != "" {
+				cmd.PrintErrf("Warning: some of these commits are already "+
+					"on %s; rewriting them changes published history.\n",
+					remote)
+			}
+			if !yes && !confirm(cmd, "Rewrite these commits? [y/N] ") {
+				cmd.PrintErrln("Branch left unchanged.")
+				return nil
+			}
+
+			backup, err := uc.Apply(cmd.Context(), plan)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("Rewrote %d commits. Previous history saved as %s.\n",
+				plan.Rewritten(), backup)
+			cmd.Printf("Undo with: git reset --keep %s\n", backup)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&opts.Body, "body", false,
+		"generate full messages with a body and footers")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", app.DefaultRewriteConcurrency,
+		"number of messages to generate at once")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false,
+		"rewrite without asking for confirmation")
+	return cmd
+}
+
+// confirm asks a yes/no question on stderr and reads the answer from stdin;
+// anything but "y" or "yes" is a no.
+func confirm(cmd *cobra.Command, question string) bool {
+	cmd.PrintErr(question)
+	line, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
@@ -3,6 +3,8 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/spf13/cobra"
 
 	"github.com/m7medvision/lazycommit/internal/app"
@@ -13,11 +15,24 @@ import (
 // cases are built lazily so `config set` still works when the active
 // backend's configuration is currently broken.
 type Deps struct {
-	NewCommitUC  func() (*app.GenerateCommitSuggestions, error)
-	NewPRUC      func() (*app.GeneratePRTitles, error)
-	ConfigRepo   *config.Repository
-	BackendNames []string
-	Version      string
+	NewCommitUC         func() (*app.GenerateCommitSuggestions, error)
+	NewPRUC             func() (*app.GeneratePRTitles, error)
+	NewPRDescriptionUC  func() (*app.GeneratePRDescription, error)
+	NewCreateCommitUC   func() (*app.CreateCommit, error)
+	NewRewordUC         func() (*app.RewordCommit, error)
+	NewRewriteBranchUC  func() (*app.RewriteBranch, error)
+	NewSquashUC         func() (*app.SquashMessage, error)
+	NewBranchUC         func() (*app.SuggestBranchNames, error)
+	NewChangelogUC      func() (*app.GenerateChangelog, error)
+	NewBumpUC           func() (*app.RecommendBump, error)
+	NewHookUC           func() (*app.ManageHook, error)
+	NewPrepareMessageUC func() (*app.PrepareCommitMessage, error)
+	ConfigRepo          *config.Repository
+	BackendNames        []string
+	// ListModels returns the models a backend can choose from at baseURL,
+	// or none when it cannot list them and the name must be typed.
+	ListModels func(ctx context.Context, backend, baseURL string) ([]string, error)
+	Version    string
 }
 
 func NewRoot(deps Deps) *cobra.Command {
@@ -27,6 +42,17 @@ func NewRoot(deps Deps) *cobra.Command {
 		Version:       deps.Version,
 		SilenceErrors: true,
 	}
-	root.AddCommand(newCommitCmd(deps), newPRCmd(deps), newConfigCmd(deps))
+	root.AddCommand(
+		newCommitCmd(deps),
+		newPRCmd(deps),
+		newRewordCmd(deps),
+		newRewriteBranchCmd(deps),
+		newSquashCmd(deps),
+		newBranchCmd(deps),
+		newChangelogCmd(deps),
+		newBumpCmd(deps),
+		newHookCmd(deps),
+		newConfigCmd(deps),
+	)
 	return root
 }
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+)
+
+func newSquashCmd(deps Deps) *cobra.Command {
+	var opts app.SquashOptions
+	cmd := &cobra.Command{
+		Use:   "squash <base-branch>",
+		Short: "Write one commit message for squashing the current branch onto a base",
+		Long: "Write a single conventional commit message for squashing the current branch\n" +
+			"onto base, from the merge-base diff and the branch's commit subjects. Only\n" +
+			"the message goes to stdout, so it can be handed to git as is. Run it on the\n" +
+			"branch being squashed:\n\n" +
+			"  lazycommit squash main > /tmp/msg\n" +
+			"  git switch main && git merge --squash feature && git commit -F /tmp/msg",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			uc, err := deps.NewSquashUC()
+			if err != nil {
+				return err
+			}
+			res, err := uc.Execute(cmd.Context(), args[0], opts)
+			if err != nil {
+				return err
+			}
+			if res.NoChanges {
+				cmd.PrintErrf("No changes against %s.\n", args[0])
+				return nil
+			}
+			cmd.Println(res.Suggestions[0].String())
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.Hint, "hint", "",
+		"extra guidance for the message, e.g. \"mention the migration\"")
+	return cmd
+}
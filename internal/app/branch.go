@@ -0,0 +1,118 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// SuggestBranchNames proposes branch names for the changes at hand: the
+// staged diff, or the unstaged one when nothing is staged.
+type SuggestBranchNames struct {
+	pipeline suggestionPipeline
+	cfg      ConfigRepository
+	branches BranchCreator
+}
+
+func NewSuggestBranchNames(
+	gen Generator,
+	diffs DiffSource,
+	cfg ConfigRepository,
+	branches BranchCreator,
+) *SuggestBranchNames {
+	return &SuggestBranchNames{
+		pipeline: suggestionPipeline{gen: gen, diffs: diffs, cfg: cfg},
+		cfg:      cfg,
+		branches: branches,
+	}
+}
+
+// BranchOptions tunes a single branch name run.
+type BranchOptions struct {
+	// Prefix goes in front of every name, e.g. "feat" for "feat/...".
+	Prefix string
+	// Ticket follows the prefix. When empty, a ticket key found in Hint
+	// (by the configured ticket pattern, or DefaultTicketPattern) is used.
+	Ticket string
+	// Hint is extra guidance from the user.
+	Hint string
+	// Avoid lists earlier suggestions; new ones must differ from them.
+	Avoid []string
+}
+
+// errInvalidBranchName is reported to the retry when a suggestion makes
+// no usable branch name.
+var errInvalidBranchName = errors.New(
+	"branch-name: must have letters or digits and pass git check-ref-format")
+
+// Execute returns kebab-case names with the prefix and ticket applied,
+// keeping only those git accepts as branch names.
+func (uc *SuggestBranchNames) Execute(
+	ctx context.Context,
+	opts BranchOptions,
+) (SuggestionsResult, error) {
+	settings, err := uc.cfg.PromptSettings()
+	if err != nil {
+		return SuggestionsResult{}, fmt.Errorf("loading configuration: %w", err)
+	}
+	ticket, _ := domain.NewTicket(opts.Ticket)
+	if ticket.String() == "" {
+		pattern := settings.TicketPattern
+		if pattern == nil {
+			pattern = domain.DefaultTicketPattern
+		}
+		ticket, _ = domain.ExtractTicket(pattern, opts.Hint)
+	}
+
+	res, err := uc.pipeline.runWith(ctx, suggestionRequest{
+		readDiff: func(
+			ctx context.Context, diffs DiffSource, exclude []string,
+		) (RawDiff, error) {
+			raw, err := diffs.StagedDiff(ctx, exclude)
+			if err != nil || strings.TrimSpace(raw.Patch) != "" ||
+				len(raw.Excluded) > 0 {
+				return raw, err
+			}
+			return diffs.WorkingTreeDiff(ctx, exclude)
+		},
+		pickTemplate: func(s PromptSettings) domain.PromptTemplate {
+			return s.BranchTemplate
+		},
+		hint:  opts.Hint,
+		avoid: opts.Avoid,
+		present: func(s domain.Suggestion) (domain.Suggestion, error) {
+			slug := domain.BranchSlug(s.String(), ticket)
+			name := domain.FormatBranchName(opts.Prefix, ticket, slug)
+			if name == "" || uc.branches.CheckBranchName(ctx, name) != nil {
+				return domain.Suggestion{}, errInvalidBranchName
+			}
+			return domain.NewSuggestion(name)
+		},
+	}, settings)
+	if err != nil || res.NoChanges {
+		return res, err
+	}
+
+	var names []domain.Suggestion
+	for _, n := range res.Suggestions {
+		if !slices.Contains(names, n) {
+			names = append(names, n)
+		}
+	}
+	return SuggestionsResult{Suggestions: names}, nil
+}
+
+// Create checks name and creates it at HEAD, switching to it.
+func (uc *SuggestBranchNames) Create(ctx context.Context, name string) error {
+	if err := uc.branches.CheckBranchName(ctx, name); err != nil {
+		return err
+	}
+	if err := uc.branches.CreateBranch(ctx, name); err != nil {
+		return fmt.Errorf("creating branch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// maxBumpDiffTokens caps the diff shown when classifying a commit that is
+// not a conventional commit, when the backend sets no smaller budget.
+const maxBumpDiffTokens = 4000
+
+// RecommendBump works out the next semantic version from the commits since
+// the latest release tag, and can tag it.
+type RecommendBump struct {
+	gen       Generator
+	diffs     DiffSource
+	cfg       ConfigRepository
+	log       CommitLog
+	tags      Tagger
+	changelog *GenerateChangelog
+}
+
+func NewRecommendBump(
+	gen Generator,
+	diffs DiffSource,
+	cfg ConfigRepository,
+	log CommitLog,
+	tags Tagger,
+) *RecommendBump {
+	return &RecommendBump{
+		gen:       gen,
+		diffs:     diffs,
+		cfg:       cfg,
+		log:       log,
+		tags:      tags,
+		changelog: NewGenerateChangelog(gen, diffs, cfg, log),
+	}
+}
+
+// BumpPlan is the recommendation and its justification, commit by commit.
+type BumpPlan struct {
+	// Current is the latest release tag, or "" before the first release.
+	Current string
+	Next    domain.Version
+	Impact  domain.ReleaseImpact
+	Commits []BumpCommit
+}
+
+// BumpCommit is one commit's classification. FromModel is set when the
+// generator classified it because its message is not conventional.
+type BumpCommit struct {
+	Commit    LoggedCommit
+	Impact    domain.ReleaseImpact
+	Reason    string
+	FromModel bool
+}
+
+// Plan classifies every non-merge commit since the latest release tag
+// (all commits when there is none, bumping from 0.0.0). Conventional
+// headers decide deterministically; the generator only sees the rest.
+func (uc *RecommendBump) Plan(ctx context.Context) (BumpPlan, error) {
+	tags, err := uc.tags.ReleaseTags(ctx)
+	if err != nil {
+		return BumpPlan{}, fmt.Errorf("reading tags: %w", err)
+	}
+	current := domain.Version{Prefix: "v"}
+	var plan BumpPlan
+	if latest, ok := domain.LatestVersion(tags); ok {
+		current, plan.Current = latest, latest.String()
+	}
+	commits, err := uc.log.RangeCommits(ctx, plan.Current, "HEAD")
+	if err != nil {
+		return BumpPlan{}, fmt.Errorf("reading commits: %w", err)
+	}
+
+	settings, err := uc.cfg.PromptSettings()
+	if err != nil {
+		return BumpPlan{}, fmt.Errorf("loading configuration: %w", err)
+	}
+	ticket := domain.NewTicketPrefix(settings.TicketFormat)
+	var budget int
+	loaded := false
+	for _, c := range commits {
+		bc := BumpCommit{Commit: c}
+		message := ticket.Strip(c.Message)
+		if impact, ok := domain.ConventionalImpact(message); ok {
+			bc.Impact, bc.Reason = impact, "conventional commit header"
+		} else {
+			if !loaded {
+				if budget, err = uc.cfg.DiffTokenBudget(); err != nil {
+					return BumpPlan{}, fmt.Errorf(
+						"loading backend configuration: %w", err)
+				}
+				loaded = true
+			}
+			bc.Impact, bc.Reason, err = uc.classify(ctx, c, settings, budget)
+			if err != nil {
+				return BumpPlan{}, fmt.Errorf(
+					"classifying %s: %w", c.ShortHash, err)
+			}
+			bc.FromModel = true
+		}
+		plan.Impact = max(plan.Impact, bc.Impact)
+		plan.Commits = append(plan.Commits, bc)
+	}
+	plan.Next = current.Bump(plan.Impact)
+	return plan, nil
+}
+
+func (uc *RecommendBump) classify(
+	ctx context.Context,
+	c LoggedCommit,
+	settings PromptSettings,
+	budget int,
+) (domain.ReleaseImpact, string, error) {
+	raw, err := uc.diffs.CommitDiff(ctx, c.Hash, settings.ExcludePaths)
+	if err != nil {
+		return domain.ImpactNone, "", fmt.Errorf("reading diff: %w", err)
+	}
+	diff, err := domain.NewDiff(raw.Patch)
+	if errors.Is(err, domain.ErrEmptyDiff) {
+		return domain.ImpactNone, "the commit changes no files", nil
+	}
+	if err != nil {
+		return domain.ImpactNone, "", err
+	}
+	limit := maxBumpDiffTokens
+	if budget > 0 {
+		limit = min(limit, budget)
+	}
+	prompt := domain.NewBumpClassifyPrompt(settings.SystemMessage, c.Message,
+		domain.FitDiff(diff, limit))
+	output, err := uc.gen.Generate(ctx, prompt)
+	if err != nil {
+		return domain.ImpactNone, "", err
+	}
+	return domain.ParseImpactAnswer(output)
+}
+
+// Tag creates plan.Next as an annotated tag whose message is the release's
+// changelog, and returns the tag name.
+func (uc *RecommendBump) Tag(
+	ctx context.Context,
+	plan BumpPlan,
+	date string,
+) (string, error) {
+	if plan.Impact == domain.ImpactNone {
+		return "", errors.New("no release is needed; nothing to tag")
+	}
+	name := plan.Next.String()
+	notes, err := uc.changelog.Release(ctx, plan.Current, "HEAD",
+		ChangelogOptions{Version: name, Date: date})
+	if err != nil {
+		return "", fmt.Errorf("writing tag message: %w", err)
+	}
+	message := "Release " + name
+	if !notes.NoChanges {
+		message += "\n\n" + notes.Markdown
+	}
+	if err := uc.tags.CreateTag(ctx, name, message); err != nil {
+		return "", fmt.Errorf("creating tag: %w", err)
+	}
+	return name, nil
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// maxChangelogCommitTokens caps each commit's diff in a changelog prompt,
+// so one large commit cannot crowd out the rest of its section.
+const maxChangelogCommitTokens = 2000
+
+// GenerateChangelog writes Keep a Changelog release notes for a revision
+// range: commits are grouped into sections by conventional type, and the
+// generator turns each section's messages and diffs into entries.
+type GenerateChangelog struct {
+	gen   Generator
+	diffs DiffSource
+	cfg   ConfigRepository
+	log   CommitLog
+}
+
+func NewGenerateChangelog(
+	gen Generator,
+	diffs DiffSource,
+	cfg ConfigRepository,
+	log CommitLog,
+) *GenerateChangelog {
+	return &GenerateChangelog{gen: gen, diffs: diffs, cfg: cfg, log: log}
+}
+
+// ChangelogOptions names the release; an empty Version renders an
+// "Unreleased" heading.
+type ChangelogOptions struct {
+	Version string
+	Date    string
+}
+
+// ChangelogResult carries the markdown, or NoChanges when the range has no
+// commit worth an entry and no backend was invoked.
+type ChangelogResult struct {
+	Markdown  string
+	NoChanges bool
+}
+
+// Execute accepts "from..to", "from.." or "from" (both meaning up to
+// HEAD).
+func (uc *GenerateChangelog) Execute(
+	ctx context.Context,
+	revRange string,
+	opts ChangelogOptions,
+) (ChangelogResult, error) {
+	from, to, err := splitRange(revRange)
+	if err != nil {
+		return ChangelogResult{}, err
+	}
+	return uc.Release(ctx, from, to, opts)
+}
+
+// Release writes the notes for from..to; an empty from covers all of to's
+// history.
+func (uc *GenerateChangelog) Release(
+	ctx context.Context,
+	from, to string,
+	opts ChangelogOptions,
+) (ChangelogResult, error) {
+	settings, err := uc.cfg.PromptSettings()
+	if err != nil {
+		return ChangelogResult{}, fmt.Errorf("loading configuration: %w", err)
+	}
+	commits, err := uc.log.RangeCommits(ctx, from, to)
+	if err != nil {
+		return ChangelogResult{}, fmt.Errorf("reading commits: %w", err)
+	}
+
+	ticket := domain.NewTicketPrefix(settings.TicketFormat)
+	grouped := make(map[string][]LoggedCommit)
+	for _, c := range commits {
+		section := domain.ChangelogSection(ticket.Strip(c.Message))
+		if section != "" {
+			grouped[section] = append(grouped[section], c)
+		}
+	}
+	if len(grouped) == 0 {
+		return ChangelogResult{NoChanges: true}, nil
+	}
+	budget, err := uc.cfg.DiffTokenBudget()
+	if err != nil {
+		return ChangelogResult{}, fmt.Errorf(
+			"loading backend configuration: %w", err)
+	}
+
+	entries := make(map[string][]string)
+	for _, section := range domain.ChangelogSections() {
+		if len(grouped[section]) == 0 {
+			continue
+		}
+		entries[section], err = uc.section(
+			ctx, section, grouped[section], settings, budget)
+		if err != nil {
+			return ChangelogResult{}, fmt.Errorf("writing %s entries: %w",
+				strings.ToLower(section), err)
+		}
+	}
+	markdown := domain.RenderChangelog(opts.Version, opts.Date, entries)
+	return ChangelogResult{Markdown: markdown}, nil
+}
+
+// section asks for the entries of one section, from its commits' messages
+// and diffs, each diff fitted to a share of the token budget.
+func (uc *GenerateChangelog) section(
+	ctx context.Context,
+	section string,
+	commits []LoggedCommit,
+	settings PromptSettings,
+	budget int,
+) ([]string, error) {
+	share := maxChangelogCommitTokens
+	if budget > 0 {
+		share = min(share, max(budget/len(commits), 1))
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "Section: %s\n", section)
+	for _, c := range commits {
+		raw, err := uc.diffs.CommitDiff(ctx, c.Hash, settings.ExcludePaths)
+		if err != nil {
+			return nil, fmt.Errorf("reading diff of %s: %w", c.ShortHash, err)
+		}
+		fmt.Fprintf(&content, "\ncommit %s\n%s\n", c.ShortHash, c.Message)
+		if diff, err := domain.NewDiff(raw.Patch); err == nil {
+			content.WriteString("\n" + domain.FitDiff(diff, share).String() + "\n")
+		}
+	}
+	diff, err := domain.NewDiff(content.String())
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := domain.NewPromptBuilder().
+		WithSystemMessage(settings.SystemMessage).
+		WithTemplate(settings.ChangelogTemplate).
+		WithLanguage(settings.Language).
+		WithDocument(true).
+		Build(diff)
+	output, err := uc.gen.Generate(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	entries := domain.ParseChangelogEntries(output)
+	if len(entries) == 0 {
+		return nil, errors.New("backend returned no entries")
+	}
+	return entries, nil
+}
+
+// splitRange parses "from..to"; a missing to means HEAD.
+func splitRange(revRange string) (from, to string, err error) {
+	if strings.Contains(revRange, "...") {
+		return "", "", fmt.Errorf(
+			"symmetric range %q is not supported; use from..to", revRange)
+	}
+	from, to, _ = strings.Cut(revRange, "..")
+	if from == "" {
+		return "", "", fmt.Errorf("range %q has no start; use from..to", revRange)
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	return from, to, nil
+}
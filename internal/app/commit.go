@@ -0,0 +1,30 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// CreateCommit commits the staged changes with a chosen suggestion.
+type CreateCommit struct {
+	writer CommitWriter
+}
+
+func NewCreateCommit(writer CommitWriter) *CreateCommit {
+	return &CreateCommit{writer: writer}
+}
+
+// Execute returns the abbreviated hash of the new commit.
+func (uc *CreateCommit) Execute(
+	ctx context.Context,
+	s domain.Suggestion,
+	flags CommitFlags,
+) (string, error) {
+	hash, err := uc.writer.Commit(ctx, s.String(), flags)
+	if err != nil {
+		return "", fmt.Errorf("creating commit: %w", err)
+	}
+	return hash, nil
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// ManageHook installs, removes, and reports lazycommit's
+// prepare-commit-msg hook.
+type ManageHook struct {
+	hooks HookInstaller
+}
+
+func NewManageHook(hooks HookInstaller) *ManageHook {
+	return &ManageHook{hooks: hooks}
+}
+
+func (uc *ManageHook) Install(ctx context.Context) (HookStatus, error) {
+	status, err := uc.hooks.InstallHook(ctx,
+		domain.PrepareCommitMsgHook, domain.PrepareCommitMsgScript)
+	if err != nil {
+		return HookStatus{}, fmt.Errorf("installing hook: %w", err)
+	}
+	return status, nil
+}
+
+func (uc *ManageHook) Uninstall(ctx context.Context) (HookStatus, error) {
+	status, err := uc.hooks.UninstallHook(ctx, domain.PrepareCommitMsgHook)
+	if err != nil {
+		return HookStatus{}, fmt.Errorf("uninstalling hook: %w", err)
+	}
+	return status, nil
+}
+
+func (uc *ManageHook) Status(ctx context.Context) (HookStatus, error) {
+	return uc.hooks.HookStatus(ctx, domain.PrepareCommitMsgHook)
+}
+
+// PrepareCommitMessage is the hook's work: draft the message for a plain
+// `git commit`.
+type PrepareCommitMessage struct {
+	suggest  *GenerateCommitSuggestions
+	comments CommentCharReader
+}
+
+func NewPrepareCommitMessage(
+	suggest *GenerateCommitSuggestions,
+	comments CommentCharReader,
+) *PrepareCommitMessage {
+	return &PrepareCommitMessage{suggest: suggest, comments: comments}
+}
+
+// Execute returns the new contents of the message file and whether they
+// changed. Only an empty source (no -m, -F, template, merge, squash, or
+// amend) is filled; nothing staged leaves the file alone.
+func (uc *PrepareCommitMessage) Execute(
+	ctx context.Context,
+	source, current string,
+) (string, bool, error) {
+	if source != "" {
+		return current, false, nil
+	}
+	res, err := uc.suggest.Execute(ctx, CommitOptions{})
+	if err != nil {
+		return "", false, err
+	}
+	if res.NoChanges {
+		return current, false, nil
+	}
+	comment, err := uc.comments.CommentChar(ctx)
+	if err != nil {
+		return "", false, fmt.Errorf("reading core.commentChar: %w", err)
+	}
+	draft := domain.DraftCommitMessage(res.Suggestions, current, comment)
+	return draft, true, nil
+}
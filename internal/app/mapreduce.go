@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// DefaultSummaryConcurrency bounds in-flight chunk summaries when the
+// configuration does not.
+const DefaultSummaryConcurrency = 4
+
+// mapReduceReducer is the map-reduce diff strategy: it splits a diff that
+// exceeds the budget into budget-sized chunks, summarizes them concurrently,
+// and hands the combined summaries to the pipeline in place of the diff.
+type mapReduceReducer struct {
+	gen         Generator
+	system      string
+	maxTokens   int
+	concurrency int
+}
+
+func (m mapReduceReducer) reduce(
+	ctx context.Context,
+	diff domain.Diff,
+) (domain.Diff, error) {
+	chunks := domain.ChunkDiff(diff, m.maxTokens)
+	summaries := make([]domain.ChunkSummary, len(chunks))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limit := m.concurrency
+	if limit <= 0 {
+		limit = DefaultSummaryConcurrency
+	}
+	sem := make(chan struct{}, limit)
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i, chunk := range chunks {
+		wg.Go(func() {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			prompt := domain.NewChunkSummaryPrompt(m.system, chunk)
+			text, err := m.gen.Generate(ctx, prompt)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("summarizing %s: %w", chunk.Label, err)
+					cancel()
+				})
+				return
+			}
+			// Each goroutine owns its index, so results land in diff order
+			// regardless of completion order.
+			summaries[i] = domain.ChunkSummary{Label: chunk.Label, Text: text}
+		})
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return domain.Diff{}, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return domain.Diff{}, err
+	}
+	return domain.SummariesDiff(summaries)
+}
@@ -5,6 +5,7 @@ package app
 
 import (
 	"context"
+	"regexp"
 
 	"github.com/m7medvision/lazycommit/internal/domain"
 )
@@ -15,22 +16,229 @@ type Generator interface {
 	Generate(ctx context.Context, prompt domain.Prompt) (string, error)
 }
 
-// DiffSource supplies raw diffs from version control.
+// RawDiff is a patch as read from version control, the structured change
+// set for the same files, and the changed paths that were left out of both
+// because they matched an exclusion glob.
+type RawDiff struct {
+	Patch    string
+	Changes  []domain.FileChange
+	Excluded []string
+}
+
+// DiffSource supplies raw diffs and related history from version control.
+// Paths matching any of the exclude globs (repository-relative, "**"
+// allowed) are kept out of the patch and reported in RawDiff.Excluded
+// instead.
 type DiffSource interface {
-	StagedDiff(ctx context.Context) (string, error)
-	BranchDiff(ctx context.Context, target string) (string, error)
+	StagedDiff(ctx context.Context, exclude []string) (RawDiff, error)
+	BranchDiff(ctx context.Context, target string, exclude []string) (RawDiff, error)
+	// WorkingTreeDiff returns the unstaged changes to tracked files.
+	WorkingTreeDiff(ctx context.Context, exclude []string) (RawDiff, error)
+	// BranchLog returns the subjects of the non-merge commits in
+	// target..HEAD, oldest first.
+	BranchLog(ctx context.Context, target string) ([]string, error)
+	// CommitDiff returns the changes an existing commit introduced.
+	CommitDiff(ctx context.Context, rev string, exclude []string) (RawDiff, error)
+	// RecentSubjects returns up to n recent non-merge commit subjects,
+	// newest first, limited to commits touching paths when paths is
+	// non-empty. A repository without commits yields none.
+	RecentSubjects(ctx context.Context, n int, paths []string) ([]string, error)
+	// CurrentBranch returns the checked-out branch name, or "" when HEAD
+	// is detached.
+	CurrentBranch(ctx context.Context) (string, error)
 }
 
+// CommitFlags are the git commit options passed through when a suggestion
+// is committed.
+type CommitFlags struct {
+	// Edit opens the message in the user's editor before committing.
+	Edit bool
+	// NoVerify skips the pre-commit and commit-msg hooks.
+	NoVerify bool
+	// Sign GPG/SSH-signs the commit; SignOff adds a Signed-off-by trailer.
+	Sign    bool
+	SignOff bool
+}
+
+// CommitWriter records the staged changes as a commit with the given
+// message and returns the new commit's abbreviated hash.
+type CommitWriter interface {
+	Commit(ctx context.Context, message string, flags CommitFlags) (string, error)
+}
+
+// CommitLog reads the commits of a revision range.
+type CommitLog interface {
+	// RangeCommits returns the non-merge commits in from..to, oldest
+	// first; an empty from means all of to's history.
+	RangeCommits(ctx context.Context, from, to string) ([]LoggedCommit, error)
+}
+
+// LoggedCommit is an existing commit and its full message.
+type LoggedCommit struct {
+	Hash      string
+	ShortHash string
+	Message   string
+}
+
+// Tagger reads and creates release tags.
+type Tagger interface {
+	// ReleaseTags lists the tags reachable from HEAD.
+	ReleaseTags(ctx context.Context) ([]string, error)
+	// CreateTag creates an annotated tag at HEAD with message kept
+	// verbatim.
+	CreateTag(ctx context.Context, name, message string) error
+}
+
+// BranchCreator checks and creates branches.
+type BranchCreator interface {
+	// CheckBranchName reports why name is not a valid branch name, as
+	// `git check-ref-format --branch` does.
+	CheckBranchName(ctx context.Context, name string) error
+	// CreateBranch creates name at HEAD and switches to it, keeping local
+	// changes.
+	CreateBranch(ctx context.Context, name string) error
+}
+
+// HistoryRewriter changes the messages of commits that already exist on
+// the current branch.
+type HistoryRewriter interface {
+	// PublishedIn returns a remote-tracking branch that contains rev, or
+	// "" when it has not been pushed.
+	PublishedIn(ctx context.Context, rev string) (string, error)
+	// Reword gives rev a new message, rewriting its descendants as needed,
+	// and returns the rewritten commit's abbreviated hash.
+	Reword(ctx context.Context, rev, message string) (string, error)
+	// BranchCommits returns HEAD's full hash and the commits in
+	// base..HEAD, oldest first.
+	BranchCommits(ctx context.Context, base string) (string, []BranchCommit, error)
+	// RewriteMessages gives the commits keyed in messages their new
+	// messages in one pass over base..head, failing if the branch moved
+	// away from head. It first saves head under a backup ref, which it
+	// returns.
+	RewriteMessages(
+		ctx context.Context,
+		base, head string,
+		messages map[string]string,
+	) (string, error)
+}
+
+// BranchCommit is one commit of a branch being rewritten.
+type BranchCommit struct {
+	Hash      string
+	ShortHash string
+	Subject   string
+	Merge     bool
+}
+
+// HookStatus describes what is installed at a hook's path.
+type HookStatus struct {
+	// Path is where git looks for the hook, honoring core.hooksPath.
+	Path string
+	// Installed is true when lazycommit's script is there; Foreign when
+	// some other hook is.
+	Installed bool
+	Foreign   bool
+	// Chained is true when a replaced hook is kept and run first.
+	Chained bool
+}
+
+// HookInstaller manages a git hook script. Installing over another hook
+// keeps that hook under domain.ChainedHookSuffix; uninstalling restores it.
+type HookInstaller interface {
+	InstallHook(ctx context.Context, name, script string) (HookStatus, error)
+	UninstallHook(ctx context.Context, name string) (HookStatus, error)
+	HookStatus(ctx context.Context, name string) (HookStatus, error)
+}
+
+// CommentCharReader reports how git marks comment lines in commit
+// messages.
+type CommentCharReader interface {
+	// CommentChar returns the character git strips comment lines by,
+	// domain.DefaultCommentChar unless core.commentChar is set.
+	CommentChar(ctx context.Context) (string, error)
+}
+
+// HistoryScope selects which commits feed the style examples.
+type HistoryScope string
+
+const (
+	// HistoryScopeRepository uses the latest commits anywhere in the repo.
+	HistoryScopeRepository HistoryScope = "repository"
+	// HistoryScopePaths prefers commits touching the files being changed,
+	// falling back to the whole repository when none exist.
+	HistoryScopePaths HistoryScope = "paths"
+)
+
+// DiffStrategy selects how a diff larger than the token budget is reduced
+// before the final prompt.
+type DiffStrategy string
+
+const (
+	// DiffStrategyTruncate shrinks the diff in place with domain.FitDiff.
+	DiffStrategyTruncate DiffStrategy = "truncate"
+	// DiffStrategyMapReduce summarizes budget-sized chunks of the diff and
+	// generates suggestions from the combined summaries.
+	DiffStrategyMapReduce DiffStrategy = "map-reduce"
+)
+
+// CommitStyle selects how strictly commit suggestions must follow a format.
+type CommitStyle string
+
+const (
+	// CommitStyleFree accepts any cleaned line.
+	CommitStyleFree CommitStyle = "free"
+	// CommitStyleConventional drops suggestions that are not Conventional
+	// Commits and normalizes the rest.
+	CommitStyleConventional CommitStyle = "conventional"
+)
+
 // PromptSettings is the effective prompt configuration after layering.
 type PromptSettings struct {
-	SystemMessage   string
-	CommitTemplate  domain.PromptTemplate
+	SystemMessage  string
+	CommitTemplate domain.PromptTemplate
+	// CommitBodyTemplate is used instead of CommitTemplate when full
+	// messages with a body are requested.
+	CommitBodyTemplate domain.PromptTemplate
+	// SquashTemplate turns a branch diff and its commit subjects into one
+	// squash message.
+	SquashTemplate  domain.PromptTemplate
 	PRTitleTemplate domain.PromptTemplate
-	Language        domain.Language
-	SuggestionCount int
+	// ChangelogTemplate turns one section's commits into entries.
+	ChangelogTemplate domain.PromptTemplate
+	// BranchTemplate asks for branch names.
+	BranchTemplate domain.PromptTemplate
+	// PRBodyTemplate asks for a full pull request description.
+	PRBodyTemplate     domain.PromptTemplate
+	Language           domain.Language
+	SuggestionCount    int
+	DiffStrategy       DiffStrategy
+	SummaryConcurrency int
+	ExcludePaths       []string
+	// HistoryExamples is how many recent commit subjects to show the model
+	// as style examples for commit messages; zero disables the step.
+	HistoryExamples int
+	HistoryScope    HistoryScope
+	// TicketPattern extracts a ticket key from the branch name; nil
+	// disables ticket prefixes. TicketFormat renders the key (one %s).
+	TicketPattern *regexp.Regexp
+	TicketFormat  string
+	// Scopes maps changed paths to the allowed conventional-commit scopes;
+	// empty disables scope inference.
+	Scopes      domain.ScopeMap
+	CommitStyle CommitStyle
+	// LintRules repair or reject commit suggestions after parsing; empty
+	// disables linting.
+	LintRules domain.LintRules
 }
 
 // ConfigRepository yields the effective settings the use cases need.
 type ConfigRepository interface {
 	PromptSettings() (PromptSettings, error)
+	// DiffTokenBudget is the active backend's estimated-token budget for
+	// the diff; zero means unlimited. Use cases ask for it only once there
+	// is a diff, so runs with nothing to do never read backend settings.
+	DiffTokenBudget() (int, error)
+	// PRLayout is the repository's pull request template, or "" when it
+	// has none. Only pull request descriptions read it.
+	PRLayout() (string, error)
 }
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// RewordCommit suggests a better message for an existing commit and, on
+// request, rewrites it.
+type RewordCommit struct {
+	pipeline suggestionPipeline
+	history  HistoryRewriter
+}
+
+func NewRewordCommit(
+	gen Generator,
+	diffs DiffSource,
+	cfg ConfigRepository,
+	history HistoryRewriter,
+) *RewordCommit {
+	return &RewordCommit{
+		pipeline: suggestionPipeline{
+			gen: gen, diffs: diffs, cfg: cfg, commitSteps: true,
+		},
+		history: history,
+	}
+}
+
+// Suggest generates messages from the diff rev introduced, using the
+// commit templates.
+func (uc *RewordCommit) Suggest(
+	ctx context.Context,
+	rev string,
+	opts CommitOptions,
+) (SuggestionsResult, error) {
+	if rev == "" {
+		return SuggestionsResult{}, errors.New("commit is required")
+	}
+	return uc.pipeline.run(ctx, uc.request(rev, opts, 0))
+}
+
+// SuggestOne is Suggest asking for a single message whatever the
+// configured count, for rewriting many commits in one go.
+func (uc *RewordCommit) SuggestOne(
+	ctx context.Context,
+	rev string,
+	opts CommitOptions,
+) (SuggestionsResult, error) {
+	if rev == "" {
+		return SuggestionsResult{}, errors.New("commit is required")
+	}
+	return uc.pipeline.run(ctx, uc.request(rev, opts, 1))
+}
+
+// request reads rev's diff with the commit templates; count overrides the
+// configured suggestion count when positive. Recent history is not offered
+// as style examples: it is the history being reworded.
+func (uc *RewordCommit) request(
+	rev string,
+	opts CommitOptions,
+	count int,
+) suggestionRequest {
+	return suggestionRequest{
+		readDiff: func(
+			ctx context.Context, diffs DiffSource, exclude []string,
+		) (RawDiff, error) {
+			return diffs.CommitDiff(ctx, rev, exclude)
+		},
+		pickTemplate: func(s PromptSettings) domain.PromptTemplate {
+			if opts.Body {
+				return s.CommitBodyTemplate
+			}
+			return s.CommitTemplate
+		},
+		fullMessages:    opts.Body,
+		hint:            opts.Hint,
+		avoid:           opts.Avoid,
+		count:           count,
+		noStyleExamples: true,
+	}
+}
+
+// CheckRewritable refuses commits that were already pushed, so rewording
+// never rewrites published history. Call it before Suggest to fail fast.
+func (uc *RewordCommit) CheckRewritable(ctx context.Context, rev string) error {
+	remote, err := uc.history.PublishedIn(ctx, rev)
+	if err != nil {
+		return err
+	}
+	if remote != "" {
+		return fmt.Errorf("commit %s is already pushed to %s; "+
+			"rewording it would rewrite published history", rev, remote)
+	}
+	return nil
+}
+
+// Apply rewrites rev's message and returns the new abbreviated hash.
+func (uc *RewordCommit) Apply(
+	ctx context.Context,
+	rev string,
+	s domain.Suggestion,
+) (string, error) {
+	if err := uc.CheckRewritable(ctx, rev); err != nil {
+		return "", err
+	}
+	hash, err := uc.history.Reword(ctx, rev, s.String())
+	if err != nil {
+		return "", fmt.Errorf("rewording %s: %w", rev, err)
+	}
+	return hash, nil
+}
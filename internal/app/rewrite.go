@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// DefaultRewriteConcurrency bounds in-flight commit messages when
+// rewriting a branch.
+const DefaultRewriteConcurrency = 4
+
+// RewritePlan is the proposed new message for every commit of a branch.
+// Merge commits and commits without changes keep their messages and have
+// a zero Message.
+type RewritePlan struct {
+	Base    string
+	Head    string
+	Entries []RewriteEntry
+}
+
+type RewriteEntry struct {
+	Commit  BranchCommit
+	Message domain.Suggestion
+}
+
+// Changed reports whether the entry gets a new message.
+func (e RewriteEntry) Changed() bool {
+	return e.Message.String() != ""
+}
+
+// Rewritten counts the entries that get a new message.
+func (p RewritePlan) Rewritten() int {
+	n := 0
+	for _, e := range p.Entries {
+		if e.Changed() {
+			n++
+		}
+	}
+	return n
+}
+
+// RewriteBranch regenerates the message of every commit on the current
+// branch since base and rewrites them in one pass.
+type RewriteBranch struct {
+	reword  *RewordCommit
+	history HistoryRewriter
+}
+
+func NewRewriteBranch(
+	reword *RewordCommit,
+	history HistoryRewriter,
+) *RewriteBranch {
+	return &RewriteBranch{reword: reword, history: history}
+}
+
+// Plan generates one message per non-merge commit, at most concurrency
+// at a time (DefaultRewriteConcurrency when not positive). The first
+// failure cancels the rest.
+func (uc *RewriteBranch) Plan(
+	ctx context.Context,
+	base string,
+	opts CommitOptions,
+	concurrency int,
+) (RewritePlan, error) {
+	if base == "" {
+		return RewritePlan{}, errors.New("base branch is required")
+	}
+	head, commits, err := uc.history.BranchCommits(ctx, base)
+	if err != nil {
+		return RewritePlan{}, err
+	}
+	if len(commits) == 0 {
+		return RewritePlan{Base: base, Head: head}, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if concurrency <= 0 {
+		concurrency = DefaultRewriteConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+
+	entries := make([]RewriteEntry, len(commits))
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i, c := range commits {
+		entries[i].Commit = c
+		if c.Merge {
+			continue
+		}
+		wg.Go(func() {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			msg, err := uc.message(ctx, c, opts)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("commit %s: %w", c.ShortHash, err)
+					cancel()
+				})
+				return
+			}
+			entries[i].Message = msg
+		})
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return RewritePlan{}, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return RewritePlan{}, err
+	}
+	return RewritePlan{Base: base, Head: head, Entries: entries}, nil
+}
+
+// message asks for a single suggestion. A commit without changes (e.g.
+// created with --allow-empty) gets a zero Suggestion and keeps its
+// message.
+func (uc *RewriteBranch) message(
+	ctx context.Context,
+	c BranchCommit,
+	opts CommitOptions,
+) (domain.Suggestion, error) {
+	res, err := uc.reword.SuggestOne(ctx, c.Hash, opts)
+	if err != nil || res.NoChanges {
+		return domain.Suggestion{}, err
+	}
+	return res.Suggestions[0], nil
+}
+
+// Apply rewrites the branch as planned and returns the backup ref holding
+// the previous history.
+func (uc *RewriteBranch) Apply(
+	ctx context.Context,
+	plan RewritePlan,
+) (string, error) {
+	messages := make(map[string]string, len(plan.Entries))
+	for _, e := range plan.Entries {
+		if e.Changed() {
+			messages[e.Commit.Hash] = e.Message.String()
+		}
+	}
+	if len(messages) == 0 {
+		return "", errors.New("nothing to rewrite")
+	}
+	backup, err := uc.history.RewriteMessages(
+		ctx, plan.Base, plan.Head, messages)
+	if err != nil {
+		return "", fmt.Errorf("rewriting branch: %w", err)
+	}
+	return backup, nil
+}
+
+// PublishedIn reports a remote-tracking branch that already has some of
+// the plan's commits, or "".
+func (uc *RewriteBranch) PublishedIn(
+	ctx context.Context,
+	plan RewritePlan,
+) (string, error) {
+	for _, e := range plan.Entries {
+		remote, err := uc.history.PublishedIn(ctx, e.Commit.Hash)
+		if err != nil || remote != "" {
+			return remote, err
+		}
+	}
+	return "", nil
+}
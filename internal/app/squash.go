@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// SquashMessage writes the single commit message for squashing the current
+// branch onto a base: a conventional subject for the whole change and a
+// body listing the notable changes, from the merge-base diff and the
+// branch's commit subjects.
+type SquashMessage struct {
+	pipeline suggestionPipeline
+}
+
+func NewSquashMessage(
+	gen Generator,
+	diffs DiffSource,
+	cfg ConfigRepository,
+) *SquashMessage {
+	return &SquashMessage{pipeline: suggestionPipeline{
+		gen: gen, diffs: diffs, cfg: cfg, commitSteps: true,
+	}}
+}
+
+// SquashOptions tunes a single squash message run.
+type SquashOptions struct {
+	// Hint is extra guidance from the user.
+	Hint string
+}
+
+// Execute returns one suggestion, or NoChanges when the branch does not
+// differ from base.
+func (uc *SquashMessage) Execute(
+	ctx context.Context,
+	base string,
+	opts SquashOptions,
+) (SuggestionsResult, error) {
+	if base == "" {
+		return SuggestionsResult{}, errors.New("base branch is required")
+	}
+	return uc.pipeline.run(ctx, suggestionRequest{
+		readDiff: func(
+			ctx context.Context, diffs DiffSource, exclude []string,
+		) (RawDiff, error) {
+			return diffs.BranchDiff(ctx, base, exclude)
+		},
+		readLog: func(ctx context.Context, diffs DiffSource) ([]string, error) {
+			return diffs.BranchLog(ctx, base)
+		},
+		pickTemplate: func(s PromptSettings) domain.PromptTemplate {
+			return s.SquashTemplate
+		},
+		fullMessages: true,
+		conventional: true,
+		hint:         opts.Hint,
+		count:        1,
+		// The branch's own commits are what is being squashed away.
+		noStyleExamples: true,
+	})
+}
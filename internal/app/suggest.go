@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
 
 	"github.com/m7medvision/lazycommit/internal/domain"
 )
@@ -21,15 +24,49 @@ type GenerateCommitSuggestions struct {
 	pipeline suggestionPipeline
 }
 
-func NewGenerateCommitSuggestions(gen Generator, diffs DiffSource, cfg ConfigRepository) *GenerateCommitSuggestions {
-	return &GenerateCommitSuggestions{pipeline: suggestionPipeline{gen: gen, diffs: diffs, cfg: cfg}}
+func NewGenerateCommitSuggestions(
+	gen Generator,
+	diffs DiffSource,
+	cfg ConfigRepository,
+) *GenerateCommitSuggestions {
+	return &GenerateCommitSuggestions{pipeline: suggestionPipeline{
+		gen:         gen,
+		diffs:       diffs,
+		cfg:         cfg,
+		commitSteps: true,
+	}}
 }
 
-func (uc *GenerateCommitSuggestions) Execute(ctx context.Context) (SuggestionsResult, error) {
-	return uc.pipeline.run(ctx, func(ctx context.Context, diffs DiffSource) (string, error) {
-		return diffs.StagedDiff(ctx)
-	}, func(s PromptSettings) domain.PromptTemplate {
-		return s.CommitTemplate
+// CommitOptions tunes a single commit suggestion run.
+type CommitOptions struct {
+	// Body asks for full messages (subject, blank line, wrapped body,
+	// optional footers) instead of subject lines.
+	Body bool
+	// Hint is extra guidance from the user, e.g. when regenerating.
+	Hint string
+	// Avoid lists earlier suggestions; new ones must differ from them.
+	Avoid []string
+}
+
+func (uc *GenerateCommitSuggestions) Execute(
+	ctx context.Context,
+	opts CommitOptions,
+) (SuggestionsResult, error) {
+	return uc.pipeline.run(ctx, suggestionRequest{
+		readDiff: func(
+			ctx context.Context, diffs DiffSource, exclude []string,
+		) (RawDiff, error) {
+			return diffs.StagedDiff(ctx, exclude)
+		},
+		pickTemplate: func(s PromptSettings) domain.PromptTemplate {
+			if opts.Body {
+				return s.CommitBodyTemplate
+			}
+			return s.CommitTemplate
+		},
+		fullMessages: opts.Body,
+		hint:         opts.Hint,
+		avoid:        opts.Avoid,
 	})
 }
 
@@ -39,41 +76,155 @@ type GeneratePRTitles struct {
 	pipeline suggestionPipeline
 }
 
-func NewGeneratePRTitles(gen Generator, diffs DiffSource, cfg ConfigRepository) *GeneratePRTitles {
-	return &GeneratePRTitles{pipeline: suggestionPipeline{gen: gen, diffs: diffs, cfg: cfg}}
+func NewGeneratePRTitles(
+	gen Generator,
+	diffs DiffSource,
+	cfg ConfigRepository,
+) *GeneratePRTitles {
+	return &GeneratePRTitles{
+		pipeline: suggestionPipeline{gen: gen, diffs: diffs, cfg: cfg},
+	}
+}
+
+// PRTitleOptions tunes a single PR title run.
+type PRTitleOptions struct {
+	// Hint is extra guidance from the user, e.g. when regenerating.
+	Hint string
+	// Avoid lists earlier titles; new ones must differ from them.
+	Avoid []string
 }
 
-func (uc *GeneratePRTitles) Execute(ctx context.Context, target string) (SuggestionsResult, error) {
+func (uc *GeneratePRTitles) Execute(
+	ctx context.Context,
+	target string,
+	opts PRTitleOptions,
+) (SuggestionsResult, error) {
+	if target == "" {
+		return SuggestionsResult{}, errors.New("target branch is required")
+	}
+	return uc.pipeline.run(ctx, suggestionRequest{
+		readDiff: func(
+			ctx context.Context, diffs DiffSource, exclude []string,
+		) (RawDiff, error) {
+			return diffs.BranchDiff(ctx, target, exclude)
+		},
+		pickTemplate: func(s PromptSettings) domain.PromptTemplate {
+			return s.PRTitleTemplate
+		},
+		hint:  opts.Hint,
+		avoid: opts.Avoid,
+	})
+}
+
+// GeneratePRDescription writes a markdown pull request description from the
+// branch's commit log and its diff against a target branch.
+type GeneratePRDescription struct {
+	pipeline suggestionPipeline
+}
+
+func NewGeneratePRDescription(
+	gen Generator,
+	diffs DiffSource,
+	cfg ConfigRepository,
+) *GeneratePRDescription {
+	return &GeneratePRDescription{
+		pipeline: suggestionPipeline{gen: gen, diffs: diffs, cfg: cfg},
+	}
+}
+
+// Execute returns the description as a single suggestion, or NoChanges
+// when the branch does not differ from target.
+func (uc *GeneratePRDescription) Execute(
+	ctx context.Context,
+	target string,
+	opts PRTitleOptions,
+) (SuggestionsResult, error) {
 	if target == "" {
 		return SuggestionsResult{}, errors.New("target branch is required")
 	}
-	return uc.pipeline.run(ctx, func(ctx context.Context, diffs DiffSource) (string, error) {
-		return diffs.BranchDiff(ctx, target)
-	}, func(s PromptSettings) domain.PromptTemplate {
-		return s.PRTitleTemplate
+	return uc.pipeline.run(ctx, suggestionRequest{
+		readDiff: func(
+			ctx context.Context, diffs DiffSource, exclude []string,
+		) (RawDiff, error) {
+			return diffs.BranchDiff(ctx, target, exclude)
+		},
+		readLog: func(ctx context.Context, diffs DiffSource) ([]string, error) {
+			return diffs.BranchLog(ctx, target)
+		},
+		pickTemplate: func(s PromptSettings) domain.PromptTemplate {
+			return s.PRBodyTemplate
+		},
+		document: true,
+		hint:     opts.Hint,
+		count:    1,
 	})
 }
 
-// suggestionPipeline is the shared flow: read diff, short-circuit when
-// empty, build prompt, generate, parse. Commit and PR generation differ
-// only in diff source and template.
+// suggestionPipeline is the shared flow from diff to parsed suggestions.
+// commitSteps adds the checks that only make sense for commit messages.
 type suggestionPipeline struct {
-	gen   Generator
-	diffs DiffSource
-	cfg   ConfigRepository
+	gen         Generator
+	diffs       DiffSource
+	cfg         ConfigRepository
+	commitSteps bool
+}
+
+// suggestionRequest is what varies between use cases and calls: where the
+// diff comes from, which template to use, whether to ask for full
+// multi-line messages, and the user's hint and earlier suggestions.
+type suggestionRequest struct {
+	readDiff     func(context.Context, DiffSource, []string) (RawDiff, error)
+	pickTemplate func(PromptSettings) domain.PromptTemplate
+	fullMessages bool
+	hint         string
+	avoid        []string
+	// count overrides the configured suggestion count when positive.
+	count int
+	// readLog lists the commits behind the diff for the prompt; nil skips
+	// it.
+	readLog func(context.Context, DiffSource) ([]string, error)
+	// conventional drops and normalizes like CommitStyleConventional,
+	// whatever the configured style.
+	conventional bool
+	// noStyleExamples leaves out the recent commit subjects, for use cases
+	// that replace those very commits.
+	noStyleExamples bool
+	// document asks for one markdown pull request description, laid out
+	// like the repository's pull request template when there is one.
+	document bool
+	// present turns a parsed suggestion into what the user is shown, as
+	// avoid lists it; an error drops the suggestion and is reported to the
+	// retry. nil shows suggestions as parsed.
+	present func(domain.Suggestion) (domain.Suggestion, error)
 }
 
 func (p suggestionPipeline) run(
 	ctx context.Context,
-	readDiff func(context.Context, DiffSource) (string, error),
-	pickTemplate func(PromptSettings) domain.PromptTemplate,
+	req suggestionRequest,
 ) (SuggestionsResult, error) {
-	raw, err := readDiff(ctx, p.diffs)
+	settings, err := p.cfg.PromptSettings()
+	if err != nil {
+		return SuggestionsResult{}, fmt.Errorf("loading configuration: %w", err)
+	}
+	return p.runWith(ctx, req, settings)
+}
+
+// runWith is run for callers that already loaded the settings.
+func (p suggestionPipeline) runWith(
+	ctx context.Context,
+	req suggestionRequest,
+	settings PromptSettings,
+) (SuggestionsResult, error) {
+	raw, err := req.readDiff(ctx, p.diffs, settings.ExcludePaths)
 	if err != nil {
 		return SuggestionsResult{}, fmt.Errorf("reading diff: %w", err)
 	}
+	if strings.TrimSpace(raw.Patch) == "" && len(raw.Excluded) > 0 {
+		// Only excluded files changed; the note below is all the model gets.
+		raw.Patch = domain.ExcludedOnlyDiff
+	}
 
-	diff, err := domain.NewDiff(raw)
+	diff, err := domain.NewDiff(raw.Patch)
 	if errors.Is(err, domain.ErrEmptyDiff) {
 		return SuggestionsResult{NoChanges: true}, nil
 	}
@@ -81,30 +232,282 @@ func (p suggestionPipeline) run(
 		return SuggestionsResult{}, err
 	}
 
-	settings, err := p.cfg.PromptSettings()
+	budget, err := p.cfg.DiffTokenBudget()
 	if err != nil {
-		return SuggestionsResult{}, fmt.Errorf("loading configuration: %w", err)
+		return SuggestionsResult{},
+			fmt.Errorf("loading backend configuration: %w", err)
+	}
+	diff, err = p.reduceDiff(ctx, diff, settings, budget)
+	if err != nil {
+		return SuggestionsResult{}, err
+	}
+
+	var examples []string
+	if !req.noStyleExamples {
+		if examples, err = p.recentSubjects(ctx, raw, settings); err != nil {
+			return SuggestionsResult{}, fmt.Errorf("reading commit history: %w", err)
+		}
+	}
+	var checks commitChecks
+	checks.ticket, checks.hasTicket, err = p.branchTicket(ctx, settings)
+	if err != nil {
+		return SuggestionsResult{}, fmt.Errorf("reading branch name: %w", err)
+	}
+	var log []string
+	if req.readLog != nil {
+		if log, err = req.readLog(ctx, p.diffs); err != nil {
+			return SuggestionsResult{}, fmt.Errorf("reading commit log: %w", err)
+		}
+	}
+	var allowedScopes []string
+	if p.commitSteps && !settings.Scopes.Empty() {
+		allowedScopes = settings.Scopes.Allowed()
+		checks.inferredScopes = settings.Scopes.Infer(changedPaths(raw))
 	}
 
-	prompt := domain.NewPromptBuilder().
+	count := settings.SuggestionCount
+	if req.count > 0 {
+		count = req.count
+	}
+	if count <= 0 {
+		count = domain.DefaultSuggestionCount
+	}
+	var rules []string
+	if p.commitSteps {
+		rules = settings.LintRules.Describe()
+	}
+	builder := domain.NewPromptBuilder().
 		WithSystemMessage(settings.SystemMessage).
-		WithTemplate(pickTemplate(settings)).
+		WithTemplate(req.pickTemplate(settings)).
 		WithLanguage(settings.Language).
-		WithSuggestionCount(settings.SuggestionCount).
-		Build(diff)
+		WithSuggestionCount(count).
+		WithChanges(raw.Changes).
+		WithExcludedPaths(raw.Excluded).
+		WithCommitLog(log).
+		WithStyleExamples(examples).
+		WithScopes(allowedScopes, checks.inferredScopes).
+		WithRules(rules).
+		WithHint(req.hint).
+		WithAvoid(req.avoid).
+		WithFullMessages(req.fullMessages).
+		WithDocument(req.document)
+	if req.document {
+		layout, err := p.cfg.PRLayout()
+		if err != nil {
+			return SuggestionsResult{},
+				fmt.Errorf("reading pull request template: %w", err)
+		}
+		builder.WithLayout(layout)
+	}
 
-	output, err := p.gen.Generate(ctx, prompt)
+	output, err := p.gen.Generate(ctx, builder.Build(diff))
 	if err != nil {
 		return SuggestionsResult{}, fmt.Errorf("generating suggestions: %w", err)
 	}
 
-	count := settings.SuggestionCount
-	if count <= 0 {
-		count = domain.DefaultSuggestionCount
+	suggestions, rejected := p.filter(output, req, settings, checks)
+	if len(suggestions) < count && len(rejected) > 0 {
+		// One retry that names the broken rules; survivors of the first
+		// attempt are kept either way.
+		retry, err := p.gen.Generate(ctx, builder.WithRejected(rejected).Build(diff))
+		if err != nil && len(suggestions) == 0 {
+			return SuggestionsResult{}, fmt.Errorf("regenerating suggestions: %w", err)
+		}
+		if err == nil {
+			more, _ := p.filter(retry, req, settings, checks)
+			for _, s := range more {
+				if !slices.Contains(suggestions, s) {
+					suggestions = append(suggestions, s)
+				}
+			}
+		}
 	}
-	suggestions := domain.ParseSuggestions(output, count)
 	if len(suggestions) == 0 {
-		return SuggestionsResult{}, errors.New("backend returned no usable suggestions")
+		return SuggestionsResult{},
+			errors.New("backend returned no usable suggestions")
+	}
+	if len(suggestions) > count {
+		suggestions = suggestions[:count]
 	}
 	return SuggestionsResult{Suggestions: suggestions}, nil
 }
+
+// notConventional is reported like a lint violation when conventional
+// style drops a suggestion, so the retry runs and says why.
+const notConventional = "conventional-format: " +
+	"the header must read type(scope): description"
+
+// repeatsEarlier is reported when a suggestion is one the user already
+// saw, so asking for more retries instead of coming back empty.
+const repeatsEarlier = "no-repeat: repeats an earlier suggestion"
+
+// commitChecks are the per-run inputs of the commit-only checks.
+type commitChecks struct {
+	inferredScopes []string
+	ticket         domain.Ticket
+	hasTicket      bool
+}
+
+// filter parses output and applies the commit-only checks: conventional
+// normalization, scope enforcement, the branch ticket prefix, and lint
+// rules. A ticket copy from the model is stripped before anything parses
+// the message; the prefix is added back by lint, so it counts toward the
+// header length. It
+// returns the survivors and, deduplicated, the error-level violations of
+// the rest, including output that is not a conventional commit. Everything
+// is parsed before filtering; callers cap the survivors.
+func (p suggestionPipeline) filter(
+	output string,
+	req suggestionRequest,
+	settings PromptSettings,
+	checks commitChecks,
+) ([]domain.Suggestion, []string) {
+	var suggestions []domain.Suggestion
+	switch {
+	case req.document:
+		if s, ok := domain.ParseDocument(output); ok {
+			suggestions = []domain.Suggestion{s}
+		}
+	case req.fullMessages:
+		suggestions = domain.ParseMessages(output, math.MaxInt)
+	default:
+		suggestions = domain.ParseSuggestions(output, math.MaxInt)
+	}
+	var rejected []string
+	reject := func(msg string) {
+		if !slices.Contains(rejected, msg) {
+			rejected = append(rejected, msg)
+		}
+	}
+	// Earlier suggestions were shown as finished messages, so compare
+	// against what this run would show.
+	keep := func(kept []domain.Suggestion, s domain.Suggestion) []domain.Suggestion {
+		if req.present != nil {
+			var err error
+			if s, err = req.present(s); err != nil {
+				reject(err.Error())
+				return kept
+			}
+		}
+		if slices.Contains(req.avoid, s.String()) {
+			reject(repeatsEarlier)
+			return kept
+		}
+		return append(kept, s)
+	}
+	if !p.commitSteps {
+		kept := suggestions[:0]
+		for _, s := range suggestions {
+			kept = keep(kept, s)
+		}
+		return kept, rejected
+	}
+	label := ""
+	if checks.hasTicket {
+		label = checks.ticket.Label(settings.TicketFormat)
+		for i, s := range suggestions {
+			suggestions[i] = checks.ticket.Strip(s)
+		}
+	}
+	if settings.CommitStyle == CommitStyleConventional || req.conventional {
+		normalized := domain.NormalizeConventional(suggestions)
+		if len(normalized) < len(suggestions) {
+			reject(notConventional)
+		}
+		suggestions = normalized
+	}
+
+	kept := suggestions[:0]
+	for _, s := range suggestions {
+		s, ok := settings.Scopes.Enforce(s, checks.inferredScopes)
+		if !ok {
+			reject("scope-enum: scope must be one of " +
+				strings.Join(settings.Scopes.Allowed(), ", "))
+			continue
+		}
+		s, violations := settings.LintRules.ApplyPrefixed(label, s)
+		for _, v := range violations {
+			if v.Level == domain.RuleError {
+				ok = false
+				reject(v.String())
+			}
+		}
+		if ok {
+			kept = keep(kept, s)
+		}
+	}
+	return kept, rejected
+}
+
+// reduceDiff applies the configured strategy to a diff over budget. The
+// map-reduce result is fitted too, as a safety net for very many chunks.
+func (p suggestionPipeline) reduceDiff(
+	ctx context.Context,
+	diff domain.Diff,
+	settings PromptSettings,
+	budget int,
+) (domain.Diff, error) {
+	if budget <= 0 || domain.EstimateTokens(diff.String()) <= budget {
+		return diff, nil
+	}
+	if settings.DiffStrategy == DiffStrategyMapReduce {
+		reducer := mapReduceReducer{
+			gen:         p.gen,
+			system:      settings.SystemMessage,
+			maxTokens:   budget,
+			concurrency: settings.SummaryConcurrency,
+		}
+		summarized, err := reducer.reduce(ctx, diff)
+		if err != nil {
+			return domain.Diff{}, fmt.Errorf("summarizing large diff: %w", err)
+		}
+		diff = summarized
+	}
+	return domain.FitDiff(diff, budget), nil
+}
+
+// recentSubjects fetches style examples when enabled, preferring commits
+// that touched the files being changed.
+func (p suggestionPipeline) recentSubjects(
+	ctx context.Context,
+	raw RawDiff,
+	settings PromptSettings,
+) ([]string, error) {
+	if !p.commitSteps || settings.HistoryExamples <= 0 {
+		return nil, nil
+	}
+	if settings.HistoryScope == HistoryScopePaths {
+		if paths := changedPaths(raw); len(paths) > 0 {
+			subjects, err := p.diffs.RecentSubjects(ctx, settings.HistoryExamples, paths)
+			if err != nil || len(subjects) > 0 {
+				return subjects, err
+			}
+		}
+	}
+	return p.diffs.RecentSubjects(ctx, settings.HistoryExamples, nil)
+}
+
+// branchTicket extracts the ticket key from the current branch when the
+// pipeline prefixes tickets and a pattern is configured.
+func (p suggestionPipeline) branchTicket(
+	ctx context.Context,
+	settings PromptSettings,
+) (domain.Ticket, bool, error) {
+	if !p.commitSteps || settings.TicketPattern == nil {
+		return domain.Ticket{}, false, nil
+	}
+	branch, err := p.diffs.CurrentBranch(ctx)
+	if err != nil {
+		return domain.Ticket{}, false, err
+	}
+	ticket, ok := domain.ExtractTicket(settings.TicketPattern, branch)
+	return ticket, ok, nil
+}
+
+func changedPaths(raw RawDiff) []string {
+	paths := make([]string, 0, len(raw.Changes))
+	for _, c := range raw.Changes {
+		paths = append(paths, c.Path)
+	}
+	return paths
+}
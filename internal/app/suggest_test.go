@@ -3,27 +3,71 @@ package app
 import (
 	"context"
 	"errors"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/m7medvision/lazycommit/internal/domain"
 )
 
 type fakeDiffSource struct {
-	staged     string
-	stagedErr  error
-	branch     string
-	branchErr  error
-	lastTarget string
+	staged      string
+	stagedErr   error
+	branch      string
+	branchErr   error
+	commit      string
+	working     string
+	log         []string
+	excluded    []string
+	changes     []domain.FileChange
+	lastTarget  string
+	lastExclude []string
+	// subjects maps a comma-joined path filter ("" for none) to history.
+	subjects      map[string][]string
+	historyCalls  []string
+	currentBranch string
 }
 
-func (f *fakeDiffSource) StagedDiff(context.Context) (string, error) {
-	return f.staged, f.stagedErr
+func (f *fakeDiffSource) StagedDiff(_ context.Context, exclude []string) (RawDiff, error) {
+	f.lastExclude = exclude
+	return RawDiff{Patch: f.staged, Changes: f.changes, Excluded: f.excluded}, f.stagedErr
 }
 
-func (f *fakeDiffSource) BranchDiff(_ context.Context, target string) (string, error) {
+func (f *fakeDiffSource) RecentSubjects(_ context.Context, n int, paths []string) ([]string, error) {
+	key := strings.Join(paths, ",")
+	f.historyCalls = append(f.historyCalls, key)
+	subjects := f.subjects[key]
+	if len(subjects) > n {
+		subjects = subjects[:n]
+	}
+	return subjects, nil
+}
+
+func (f *fakeDiffSource) CurrentBranch(context.Context) (string, error) {
+	return f.currentBranch, nil
+}
+
+func (f *fakeDiffSource) CommitDiff(_ context.Context, rev string, exclude []string) (RawDiff, error) {
+	f.lastTarget = rev
+	f.lastExclude = exclude
+	return RawDiff{Patch: f.commit, Excluded: f.excluded}, nil
+}
+
+func (f *fakeDiffSource) WorkingTreeDiff(_ context.Context, exclude []string) (RawDiff, error) {
+	f.lastExclude = exclude
+	return RawDiff{Patch: f.working}, nil
+}
+
+func (f *fakeDiffSource) BranchLog(context.Context, string) ([]string, error) {
+	return f.log, nil
+}
+
+func (f *fakeDiffSource) BranchDiff(_ context.Context, target string, exclude []string) (RawDiff, error) {
 	f.lastTarget = target
-	return f.branch, f.branchErr
+	f.lastExclude = exclude
+	return RawDiff{Patch: f.branch, Excluded: f.excluded}, f.branchErr
 }
 
 type fakeGenerator struct {
@@ -41,29 +85,61 @@ func (f *fakeGenerator) Generate(_ context.Context, p domain.Prompt) (string, er
 
 type fakeConfig struct {
 	settings PromptSettings
+	budget   int
+	layout   string
 	err      error
+	loads    int
 }
 
 func (f *fakeConfig) PromptSettings() (PromptSettings, error) {
+	f.loads++
 	return f.settings, f.err
 }
 
+func (f *fakeConfig) DiffTokenBudget() (int, error) {
+	return f.budget, f.err
+}
+
+func (f *fakeConfig) PRLayout() (string, error) {
+	return f.layout, f.err
+}
+
 func testSettings(t *testing.T) PromptSettings {
 	t.Helper()
 	commit, err := domain.NewPromptTemplate("COMMIT %s")
 	if err != nil {
 		t.Fatal(err)
 	}
+	body, err := domain.NewPromptTemplate("BODY %s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	squash, err := domain.NewPromptTemplate("SQUASH %s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prBody, err := domain.NewPromptTemplate("PRBODY %s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch, err := domain.NewPromptTemplate("BRANCH %s")
+	if err != nil {
+		t.Fatal(err)
+	}
 	pr, err := domain.NewPromptTemplate("PR %s")
 	if err != nil {
 		t.Fatal(err)
 	}
 	return PromptSettings{
-		SystemMessage:   "sys",
-		CommitTemplate:  commit,
-		PRTitleTemplate: pr,
-		Language:        domain.NewLanguage("English"),
-		SuggestionCount: 3,
+		SystemMessage:      "sys",
+		CommitTemplate:     commit,
+		CommitBodyTemplate: body,
+		SquashTemplate:     squash,
+		PRTitleTemplate:    pr,
+		PRBodyTemplate:     prBody,
+		BranchTemplate:     branch,
+		Language:           domain.NewLanguage("English"),
+		SuggestionCount:    3,
 	}
 }
 
@@ -73,7 +149,7 @@ func TestCommitSuggestionsHappyPath(t *testing.T) {
 		&fakeDiffSource{staged: "+change"},
 		&fakeConfig{settings: testSettings(t)})
 
-	res, err := uc.Execute(context.Background())
+	res, err := uc.Execute(context.Background(), CommitOptions{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -102,7 +178,7 @@ func TestCommitSuggestionsEmptyDiffShortCircuits(t *testing.T) {
 		&fakeDiffSource{staged: "   \n"},
 		&fakeConfig{settings: testSettings(t)})
 
-	res, err := uc.Execute(context.Background())
+	res, err := uc.Execute(context.Background(), CommitOptions{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -120,7 +196,7 @@ func TestCommitSuggestionsGeneratorFailure(t *testing.T) {
 		&fakeDiffSource{staged: "+change"},
 		&fakeConfig{settings: testSettings(t)})
 
-	if _, err := uc.Execute(context.Background()); err == nil || !strings.Contains(err.Error(), "backend down") {
+	if _, err := uc.Execute(context.Background(), CommitOptions{}); err == nil || !strings.Contains(err.Error(), "backend down") {
 		t.Fatalf("expected wrapped backend error, got %v", err)
 	}
 }
@@ -131,7 +207,7 @@ func TestCommitSuggestionsNoUsableOutput(t *testing.T) {
 		&fakeDiffSource{staged: "+change"},
 		&fakeConfig{settings: testSettings(t)})
 
-	if _, err := uc.Execute(context.Background()); err == nil {
+	if _, err := uc.Execute(context.Background(), CommitOptions{}); err == nil {
 		t.Fatal("expected error when output parses to nothing")
 	}
 }
@@ -142,7 +218,7 @@ func TestCommitSuggestionsConfigFailure(t *testing.T) {
 		&fakeDiffSource{staged: "+change"},
 		&fakeConfig{err: errors.New("bad yaml")})
 
-	if _, err := uc.Execute(context.Background()); err == nil || !strings.Contains(err.Error(), "bad yaml") {
+	if _, err := uc.Execute(context.Background(), CommitOptions{}); err == nil || !strings.Contains(err.Error(), "bad yaml") {
 		t.Fatalf("expected wrapped config error, got %v", err)
 	}
 }
@@ -152,7 +228,7 @@ func TestPRTitlesUsesTargetAndTemplate(t *testing.T) {
 	diffs := &fakeDiffSource{branch: "+branch change"}
 	uc := NewGeneratePRTitles(gen, diffs, &fakeConfig{settings: testSettings(t)})
 
-	res, err := uc.Execute(context.Background(), "main")
+	res, err := uc.Execute(context.Background(), "main", PRTitleOptions{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -167,9 +243,32 @@ func TestPRTitlesUsesTargetAndTemplate(t *testing.T) {
 	}
 }
 
+func TestPRDescriptionFollowsRepositoryTemplate(t *testing.T) {
+	gen := &fakeGenerator{output: "## What\n\nAdds login.\n\n---\n\n## Checklist\n- [x] tests"}
+	diffs := &fakeDiffSource{branch: "+branch change", log: []string{"add login"}}
+	layout := "## What\n\n## Checklist\n- [ ] tests"
+	uc := NewGeneratePRDescription(gen, diffs, &fakeConfig{settings: testSettings(t), layout: layout})
+
+	res, err := uc.Execute(context.Background(), "main", PRTitleOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Suggestions) != 1 || res.Suggestions[0].String() != gen.output {
+		t.Fatalf("description not kept whole: %q", res.Suggestions)
+	}
+	for _, want := range []string{"PRBODY +branch change", "- add login", "template instead", layout, "markdown document only"} {
+		if !strings.Contains(gen.lastPrompt.User, want) {
+			t.Fatalf("prompt missing %q: %q", want, gen.lastPrompt.User)
+		}
+	}
+	if strings.Contains(gen.lastPrompt.User, "Generate exactly") {
+		t.Fatalf("document prompt asks for a count: %q", gen.lastPrompt.User)
+	}
+}
+
 func TestPRTitlesRequiresTarget(t *testing.T) {
 	uc := NewGeneratePRTitles(&fakeGenerator{}, &fakeDiffSource{}, &fakeConfig{settings: testSettings(t)})
-	if _, err := uc.Execute(context.Background(), ""); err == nil {
+	if _, err := uc.Execute(context.Background(), "", PRTitleOptions{}); err == nil {
 		t.Fatal("expected error for missing target branch")
 	}
 }
@@ -178,7 +277,7 @@ func TestPRTitlesEmptyBranchDiff(t *testing.T) {
 	gen := &fakeGenerator{}
 	uc := NewGeneratePRTitles(gen, &fakeDiffSource{branch: ""}, &fakeConfig{settings: testSettings(t)})
 
-	res, err := uc.Execute(context.Background(), "main")
+	res, err := uc.Execute(context.Background(), "main", PRTitleOptions{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -189,3 +288,865 @@ func TestPRTitlesEmptyBranchDiff(t *testing.T) {
 		t.Fatal("generator must not be called on empty diff")
 	}
 }
+
+func TestSquashMessageUsesBranchLogAndOneConventionalMessage(t *testing.T) {
+	gen := &fakeGenerator{output: "Add login flow\n" + domain.MessageSeparator + "\nfeat(auth): add login flow\n\n- add the login form\n- store the session\n" + domain.MessageSeparator + "\nfeat: other"}
+	diffs := &fakeDiffSource{branch: "+branch change", log: []string{"wip", "fix typo"}}
+	settings := testSettings(t)
+	settings.HistoryExamples = 5
+	uc := NewSquashMessage(gen, diffs, &fakeConfig{settings: settings})
+
+	res, err := uc.Execute(context.Background(), "main", SquashOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Suggestions) != 1 || res.Suggestions[0].String() != "feat(auth): add login flow\n\n- add the login form\n- store the session" {
+		t.Fatalf("unexpected suggestions: %q", res.Suggestions)
+	}
+	if diffs.lastTarget != "main" || !strings.HasPrefix(gen.lastPrompt.User, "SQUASH +branch change") {
+		t.Fatalf("branch diff or template not used: %q %q", diffs.lastTarget, gen.lastPrompt.User)
+	}
+	if !strings.Contains(gen.lastPrompt.User, "oldest first:\n- wip\n- fix typo") || !strings.Contains(gen.lastPrompt.User, "exactly 1 suggestion.") {
+		t.Fatalf("commit log or count missing from prompt: %q", gen.lastPrompt.User)
+	}
+	if len(diffs.historyCalls) != 0 {
+		t.Fatal("the commits being squashed must not be offered as style examples")
+	}
+}
+
+func TestSquashMessageRetriesNonConventionalOutput(t *testing.T) {
+	gen := &scriptedGenerator{outputs: []string{"Add login flow\n\n- add the login form", "feat(auth): add login flow\n\n- add the login form"}}
+	uc := NewSquashMessage(gen, &fakeDiffSource{branch: "+branch change"}, &fakeConfig{settings: testSettings(t)})
+
+	res, err := uc.Execute(context.Background(), "main", SquashOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Suggestions) != 1 || res.Suggestions[0].String() != "feat(auth): add login flow\n\n- add the login form" {
+		t.Fatalf("unexpected suggestions: %q", res.Suggestions)
+	}
+	if len(gen.prompts) != 2 || !strings.Contains(gen.prompts[1].User, notConventional) {
+		t.Fatalf("retry should name the conventional format: %d prompts", len(gen.prompts))
+	}
+}
+
+func TestCommitSuggestionsFitDiffToBudget(t *testing.T) {
+	huge := "diff --git a/a b/a\n--- a/a\n+++ b/a\n@@ -1 +1 @@\n+" + strings.Repeat("x", 4000) + "\n"
+	settings := testSettings(t)
+	budget := 100
+	gen := &fakeGenerator{output: "feat: one"}
+	uc := NewGenerateCommitSuggestions(gen, &fakeDiffSource{staged: huge}, &fakeConfig{settings: settings, budget: budget})
+
+	if _, err := uc.Execute(context.Background(), CommitOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(gen.lastPrompt.User, strings.Repeat("x", 4000)) {
+		t.Fatal("oversized diff reached the generator unchanged")
+	}
+	if !strings.Contains(gen.lastPrompt.User, "a: 1 of 1 hunks omitted") {
+		t.Fatalf("prompt should summarize what was dropped: %q", gen.lastPrompt.User)
+	}
+}
+
+// summarizingGenerator answers chunk-summary prompts with the chunk's
+// directory and records the final prompt; it is safe for concurrent use.
+type summarizingGenerator struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	final       domain.Prompt
+}
+
+func (g *summarizingGenerator) Generate(_ context.Context, p domain.Prompt) (string, error) {
+	g.mu.Lock()
+	g.inFlight++
+	g.maxInFlight = max(g.maxInFlight, g.inFlight)
+	g.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.inFlight--
+
+	if strings.Contains(p.User, "larger git diff touching ") {
+		dir := strings.SplitN(p.User[strings.Index(p.User, "touching ")+len("touching "):], ".", 2)[0]
+		return "summary of " + dir, nil
+	}
+	g.final = p
+	return "chore: bump vendored modules", nil
+}
+
+func TestCommitSuggestionsMapReduceLargeDiff(t *testing.T) {
+	var diff strings.Builder
+	for _, dir := range []string{"a", "b", "c", "d", "e", "f"} {
+		p := dir + "/file.go"
+		diff.WriteString("diff --git a/" + p + " b/" + p + "\n--- a/" + p + "\n+++ b/" + p + "\n@@ -1 +1 @@\n+" +
+			strings.Repeat(dir, 300) + "\n")
+	}
+	settings := testSettings(t)
+	budget := 150
+	settings.DiffStrategy = DiffStrategyMapReduce
+	settings.SummaryConcurrency = 2
+	gen := &summarizingGenerator{}
+	uc := NewGenerateCommitSuggestions(gen, &fakeDiffSource{staged: diff.String()}, &fakeConfig{settings: settings, budget: budget})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Suggestions) != 1 || res.Suggestions[0].String() != "chore: bump vendored modules" {
+		t.Fatalf("unexpected suggestions: %v", res.Suggestions)
+	}
+	if gen.maxInFlight > 2 {
+		t.Fatalf("concurrency bound exceeded: %d in flight", gen.maxInFlight)
+	}
+	last := -1
+	for _, dir := range []string{"a", "b", "c", "d", "e", "f"} {
+		i := strings.Index(gen.final.User, "["+dir+"]\nsummary of "+dir)
+		if i < 0 || i < last {
+			t.Fatalf("chunk summaries missing or out of diff order in final prompt: %q", gen.final.User)
+		}
+		last = i
+	}
+}
+
+func TestCommitSuggestionsMapReduceFailure(t *testing.T) {
+	settings := testSettings(t)
+	budget := 10
+	settings.DiffStrategy = DiffStrategyMapReduce
+	uc := NewGenerateCommitSuggestions(&fakeGenerator{err: errors.New("backend down")},
+		&fakeDiffSource{staged: "diff --git a/x b/x\n+" + strings.Repeat("x", 200) + "\n"},
+		&fakeConfig{settings: settings, budget: budget})
+
+	if _, err := uc.Execute(context.Background(), CommitOptions{}); err == nil || !strings.Contains(err.Error(), "backend down") {
+		t.Fatalf("expected summarization error, got %v", err)
+	}
+}
+
+func TestCommitSuggestionsPassExclusionsAndNoteExcluded(t *testing.T) {
+	settings := testSettings(t)
+	settings.ExcludePaths = []string{"**/go.sum"}
+	gen := &fakeGenerator{output: "chore: bump deps"}
+	diffs := &fakeDiffSource{staged: "+require x v2", excluded: []string{"go.sum", "web/pnpm-lock.yaml"}}
+	uc := NewGenerateCommitSuggestions(gen, diffs, &fakeConfig{settings: settings})
+
+	if _, err := uc.Execute(context.Background(), CommitOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(diffs.lastExclude, ",") != "**/go.sum" {
+		t.Fatalf("exclude globs not passed to the diff source: %v", diffs.lastExclude)
+	}
+	if !strings.Contains(gen.lastPrompt.User, "Also updated (not shown in the diff): go.sum, web/pnpm-lock.yaml.") {
+		t.Fatalf("excluded files not noted: %q", gen.lastPrompt.User)
+	}
+}
+
+func TestCommitSuggestionsOnlyExcludedChanges(t *testing.T) {
+	gen := &fakeGenerator{output: "chore: update go.sum"}
+	uc := NewGenerateCommitSuggestions(gen,
+		&fakeDiffSource{staged: "", excluded: []string{"go.sum"}},
+		&fakeConfig{settings: testSettings(t)})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.NoChanges || gen.calls != 1 {
+		t.Fatal("changes to excluded files alone are still changes")
+	}
+	if !strings.Contains(gen.lastPrompt.User, "go.sum") {
+		t.Fatalf("excluded file missing from prompt: %q", gen.lastPrompt.User)
+	}
+}
+
+func TestCommitSuggestionsStyleExamplesFromPaths(t *testing.T) {
+	settings := testSettings(t)
+	settings.HistoryExamples = 2
+	settings.HistoryScope = HistoryScopePaths
+	gen := &fakeGenerator{output: "billing: refund flow"}
+	diffs := &fakeDiffSource{
+		staged:  "+change",
+		changes: []domain.FileChange{{Status: domain.ChangeModified, Path: "billing/pay.go"}},
+		subjects: map[string][]string{
+			"":               {"unrelated"},
+			"billing/pay.go": {"billing: add invoices", "billing: fix rounding", "billing: older"},
+		},
+	}
+	uc := NewGenerateCommitSuggestions(gen, diffs, &fakeConfig{settings: settings})
+
+	if _, err := uc.Execute(context.Background(), CommitOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(gen.lastPrompt.User, "Match their style") ||
+		!strings.Contains(gen.lastPrompt.User, "billing: add invoices\nbilling: fix rounding") ||
+		strings.Contains(gen.lastPrompt.User, "billing: older") {
+		t.Fatalf("style examples missing or not capped: %q", gen.lastPrompt.User)
+	}
+}
+
+func TestCommitSuggestionsStyleExamplesFallBackToRepository(t *testing.T) {
+	settings := testSettings(t)
+	settings.HistoryExamples = 5
+	settings.HistoryScope = HistoryScopePaths
+	gen := &fakeGenerator{output: "feat: x"}
+	diffs := &fakeDiffSource{
+		staged:   "+change",
+		changes:  []domain.FileChange{{Status: domain.ChangeAdded, Path: "new.go"}},
+		subjects: map[string][]string{"": {"feat: repo wide"}},
+	}
+	uc := NewGenerateCommitSuggestions(gen, diffs, &fakeConfig{settings: settings})
+
+	if _, err := uc.Execute(context.Background(), CommitOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(diffs.historyCalls, "|") != "new.go|" {
+		t.Fatalf("expected path lookup then repository fallback, got %q", diffs.historyCalls)
+	}
+	if !strings.Contains(gen.lastPrompt.User, "feat: repo wide") {
+		t.Fatalf("fallback examples missing: %q", gen.lastPrompt.User)
+	}
+}
+
+func TestPRTitlesSkipStyleExamples(t *testing.T) {
+	settings := testSettings(t)
+	settings.HistoryExamples = 5
+	diffs := &fakeDiffSource{branch: "+change", subjects: map[string][]string{"": {"feat: x"}}}
+	uc := NewGeneratePRTitles(&fakeGenerator{output: "title"}, diffs, &fakeConfig{settings: settings})
+
+	if _, err := uc.Execute(context.Background(), "main", PRTitleOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diffs.historyCalls) != 0 {
+		t.Fatal("PR titles must not read commit history")
+	}
+}
+
+func TestCommitSuggestionsEnforceBranchTicket(t *testing.T) {
+	settings := testSettings(t)
+	settings.TicketPattern = regexp.MustCompile(`([A-Z]+-\d+)`)
+	settings.TicketFormat = "%s: "
+	gen := &fakeGenerator{output: "feat: add refunds\nPAY-1234: fix rounding\n[pay-1234] docs: explain refunds"}
+	uc := NewGenerateCommitSuggestions(gen,
+		&fakeDiffSource{staged: "+change", currentBranch: "feature/PAY-1234-refund-flow"},
+		&fakeConfig{settings: settings})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"PAY-1234: feat: add refunds", "PAY-1234: fix rounding", "PAY-1234: docs: explain refunds"}
+	for i, s := range res.Suggestions {
+		if s.String() != want[i] {
+			t.Fatalf("suggestion %d = %q, want %q", i, s.String(), want[i])
+		}
+	}
+}
+
+func TestCommitSuggestionsTicketCountsTowardLint(t *testing.T) {
+	rules, err := domain.NewLintRules([]domain.RuleSpec{
+		{Name: domain.RuleTypeEnum, Level: domain.RuleError, Value: []string{"feat", "fix"}},
+		{Name: domain.RuleHeaderMaxLength, Level: domain.RuleError, Value: 26},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := testSettings(t)
+	settings.TicketPattern = regexp.MustCompile(`[A-Z]+-\d+`)
+	settings.TicketFormat = "%s: "
+	settings.CommitStyle = CommitStyleConventional
+	settings.LintRules = rules
+	uc := NewGenerateCommitSuggestions(&fakeGenerator{output: "feat: add refund flow"},
+		&fakeDiffSource{staged: "+change", currentBranch: "PAY-1234-refunds"},
+		&fakeConfig{settings: settings})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := res.Suggestions[0].String(); got != "PAY-1234: feat: add refund" {
+		t.Fatalf("suggestion = %q", got)
+	}
+}
+
+func TestCommitSuggestionsCustomTicketFormatPassesTypeEnum(t *testing.T) {
+	rules, err := domain.NewLintRules([]domain.RuleSpec{
+		{Name: domain.RuleTypeEnum, Level: domain.RuleError, Value: []string{"feat"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := testSettings(t)
+	settings.TicketPattern = regexp.MustCompile(`[A-Z]+-\d+`)
+	settings.TicketFormat = "%s - "
+	settings.LintRules = rules
+	uc := NewGenerateCommitSuggestions(
+		&fakeGenerator{output: "PAY-1234 - feat: add refund flow"},
+		&fakeDiffSource{staged: "+change", currentBranch: "PAY-1234-refunds"},
+		&fakeConfig{settings: settings})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := res.Suggestions[0].String(); got != "PAY-1234 - feat: add refund flow" {
+		t.Fatalf("suggestion = %q", got)
+	}
+}
+
+func TestCommitSuggestionsNoTicketOnNonMatchingBranch(t *testing.T) {
+	settings := testSettings(t)
+	settings.TicketPattern = regexp.MustCompile(`[A-Z]+-\d+`)
+	settings.TicketFormat = "%s: "
+	uc := NewGenerateCommitSuggestions(&fakeGenerator{output: "feat: x"},
+		&fakeDiffSource{staged: "+change", currentBranch: "main"},
+		&fakeConfig{settings: settings})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Suggestions[0].String() != "feat: x" {
+		t.Fatalf("unexpected prefix: %q", res.Suggestions[0].String())
+	}
+}
+
+func TestCommitSuggestionsRetryRejectedScope(t *testing.T) {
+	scopes, err := domain.NewScopeMap(map[string]string{"billing/**": "billing", "web/**": "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := testSettings(t)
+	settings.Scopes = scopes
+	gen := &scriptedGenerator{outputs: []string{"feat(payments): add refunds", "feat(billing): add refunds"}}
+	uc := NewGenerateCommitSuggestions(gen, &fakeDiffSource{
+		staged: "+change",
+		changes: []domain.FileChange{
+			{Status: domain.ChangeModified, Path: "billing/refund.go"},
+			{Status: domain.ChangeModified, Path: "web/refund.ts"},
+		},
+	}, &fakeConfig{settings: settings})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Suggestions) != 1 || res.Suggestions[0].String() != "feat(billing): add refunds" {
+		t.Fatalf("unexpected suggestions: %q", res.Suggestions)
+	}
+	if len(gen.prompts) != 2 || !strings.Contains(gen.prompts[1].User, "scope-enum: scope must be one of billing, web") {
+		t.Fatalf("retry should name the allowed scopes: %d prompts", len(gen.prompts))
+	}
+}
+
+func TestCommitSuggestionsEnforceScopes(t *testing.T) {
+	scopes, err := domain.NewScopeMap(map[string]string{"billing/**": "billing", "web/**": "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := testSettings(t)
+	settings.Scopes = scopes
+	gen := &fakeGenerator{output: "feat(payments): add refunds\nfix(web): align button\nchore: tidy"}
+	uc := NewGenerateCommitSuggestions(gen, &fakeDiffSource{
+		staged:  "+change",
+		changes: []domain.FileChange{{Status: domain.ChangeModified, Path: "billing/refund.go"}},
+	}, &fakeConfig{settings: settings})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make([]string, len(res.Suggestions))
+	for i, s := range res.Suggestions {
+		got[i] = s.String()
+	}
+	want := "feat(billing): add refunds|fix(web): align button|chore(billing): tidy"
+	if strings.Join(got, "|") != want {
+		t.Fatalf("suggestions = %q, want %q", strings.Join(got, "|"), want)
+	}
+	if !strings.Contains(gen.lastPrompt.User, "Use only these conventional commit scopes: billing, web.") ||
+		!strings.Contains(gen.lastPrompt.User, "The changed files belong to: billing.") {
+		t.Fatalf("scopes missing from prompt: %q", gen.lastPrompt.User)
+	}
+}
+
+func TestCommitSuggestionsConventionalStyle(t *testing.T) {
+	settings := testSettings(t)
+	settings.CommitStyle = CommitStyleConventional
+	uc := NewGenerateCommitSuggestions(
+		&fakeGenerator{output: "# Suggestions\nFeat: Add login.\nupdated stuff\nfix(auth): handle expiry"},
+		&fakeDiffSource{staged: "+change"},
+		&fakeConfig{settings: settings})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Suggestions) != 2 || res.Suggestions[0].String() != "feat: add login" ||
+		res.Suggestions[1].String() != "fix(auth): handle expiry" {
+		t.Fatalf("unexpected suggestions: %v", res.Suggestions)
+	}
+}
+
+func TestCommitSuggestionsBodyModeKeepsFullMessages(t *testing.T) {
+	gen := &fakeGenerator{output: "feat: add login\n\nUsers asked for it.\n\nRefs: #12\n" + domain.MessageSeparator + "\nfix: guard nil session\n\nThe handler panicked when the cookie was missing."}
+	settings := testSettings(t)
+	settings.CommitStyle = CommitStyleConventional
+	uc := NewGenerateCommitSuggestions(gen, &fakeDiffSource{staged: "+change"}, &fakeConfig{settings: settings})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{Body: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Suggestions) != 2 {
+		t.Fatalf("suggestions = %q", res.Suggestions)
+	}
+	if got := res.Suggestions[0].String(); got != "feat: add login\n\nUsers asked for it.\n\nRefs: #12" {
+		t.Fatalf("first message = %q", got)
+	}
+	if res.Suggestions[1].Subject() != "fix: guard nil session" {
+		t.Fatalf("second subject = %q", res.Suggestions[1].Subject())
+	}
+	if !strings.HasPrefix(gen.lastPrompt.User, "BODY +change") || !strings.Contains(gen.lastPrompt.User, "only "+domain.MessageSeparator) {
+		t.Fatalf("body template or separator instruction missing: %q", gen.lastPrompt.User)
+	}
+}
+
+// scriptedGenerator answers successive calls with successive outputs.
+type scriptedGenerator struct {
+	outputs []string
+	prompts []domain.Prompt
+}
+
+func (g *scriptedGenerator) Generate(_ context.Context, p domain.Prompt) (string, error) {
+	g.prompts = append(g.prompts, p)
+	out := g.outputs[0]
+	if len(g.outputs) > 1 {
+		g.outputs = g.outputs[1:]
+	}
+	return out, nil
+}
+
+func TestCommitSuggestionsLintRepairsAndRetries(t *testing.T) {
+	rules, err := domain.NewLintRules([]domain.RuleSpec{
+		{Name: domain.RuleTypeEnum, Level: domain.RuleError, Value: []string{"feat", "fix"}},
+		{Name: domain.RuleSubjectFullStop, Level: domain.RuleError, Never: true, Value: "."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := testSettings(t)
+	settings.LintRules = rules
+	gen := &scriptedGenerator{outputs: []string{
+		"feat: add login.\nbuild: bump deps\nwip",
+		"feat: add login\nfix: guard nil\nfix: handle empty diff",
+	}}
+	uc := NewGenerateCommitSuggestions(gen, &fakeDiffSource{staged: "+change"}, &fakeConfig{settings: settings})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := make([]string, len(res.Suggestions))
+	for i, s := range res.Suggestions {
+		got[i] = s.String()
+	}
+	if want := "feat: add login|fix: guard nil|fix: handle empty diff"; strings.Join(got, "|") != want {
+		t.Fatalf("suggestions = %q, want %q", got, want)
+	}
+	if len(gen.prompts) != 2 {
+		t.Fatalf("expected one retry, got %d calls", len(gen.prompts))
+	}
+	if !strings.Contains(gen.prompts[0].User, "the type must be one of: feat, fix") {
+		t.Fatalf("rules not in prompt: %q", gen.prompts[0].User)
+	}
+	if retry := gen.prompts[1].User; !strings.Contains(retry, `type-enum: "build: bump deps" has type "build"`) ||
+		!strings.Contains(retry, `type-enum: "wip" has no type`) {
+		t.Fatalf("retry must list the violations: %q", retry)
+	}
+}
+
+func TestCommitSuggestionsHintAndAvoid(t *testing.T) {
+	gen := &fakeGenerator{output: "feat: one\nfeat: two\nfeat: three"}
+	uc := NewGenerateCommitSuggestions(gen, &fakeDiffSource{staged: "+change"}, &fakeConfig{settings: testSettings(t)})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{Hint: "mention the cache", Avoid: []string{"feat: one"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Suggestions) != 2 || res.Suggestions[0].String() != "feat: two" {
+		t.Fatalf("avoided suggestion returned again: %v", res.Suggestions)
+	}
+	if !strings.Contains(gen.lastPrompt.User, "Guidance from the user: mention the cache") ||
+		!strings.Contains(gen.lastPrompt.User, "propose different ones:\nfeat: one") {
+		t.Fatalf("hint or earlier suggestions missing: %q", gen.lastPrompt.User)
+	}
+}
+
+func TestCommitSuggestionsAvoidMatchesTicketedSuggestions(t *testing.T) {
+	settings := testSettings(t)
+	settings.TicketPattern = regexp.MustCompile(`[A-Z]+-\d+`)
+	settings.TicketFormat = "%s: "
+	uc := NewGenerateCommitSuggestions(&fakeGenerator{output: "feat: one\nfeat: two"},
+		&fakeDiffSource{staged: "+change", currentBranch: "PAY-1234-refunds"},
+		&fakeConfig{settings: settings})
+
+	res, err := uc.Execute(context.Background(), CommitOptions{Avoid: []string{"PAY-1234: feat: one"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Suggestions) != 1 || res.Suggestions[0].String() != "PAY-1234: feat: two" {
+		t.Fatalf("avoided suggestion returned again: %q", res.Suggestions)
+	}
+}
+
+func TestAvoidedSuggestionsTriggerRetry(t *testing.T) {
+	ctx := context.Background()
+	settings := testSettings(t)
+
+	gen := &scriptedGenerator{outputs: []string{"feat: one", "feat: two"}}
+	commit := NewGenerateCommitSuggestions(gen, &fakeDiffSource{staged: "+change"}, &fakeConfig{settings: settings})
+	res, err := commit.Execute(ctx, CommitOptions{Avoid: []string{"feat: one"}})
+	if err != nil || len(res.Suggestions) != 1 || res.Suggestions[0].String() != "feat: two" {
+		t.Fatalf("commit: %q, %v", res.Suggestions, err)
+	}
+	if !strings.Contains(gen.prompts[1].User, repeatsEarlier) {
+		t.Fatalf("retry should say the suggestion repeated: %q", gen.prompts[1].User)
+	}
+
+	gen = &scriptedGenerator{outputs: []string{"Add login", "Add login flow"}}
+	pr := NewGeneratePRTitles(gen, &fakeDiffSource{branch: "+change"}, &fakeConfig{settings: settings})
+	res, err = pr.Execute(ctx, "main", PRTitleOptions{Avoid: []string{"Add login"}})
+	if err != nil || len(res.Suggestions) != 1 || res.Suggestions[0].String() != "Add login flow" {
+		t.Fatalf("pr: %q, %v", res.Suggestions, err)
+	}
+
+	gen = &scriptedGenerator{outputs: []string{"add login", "add login flow"}}
+	branch := NewSuggestBranchNames(gen, &fakeDiffSource{staged: "+change"}, &fakeConfig{settings: settings}, &fakeBranches{})
+	res, err = branch.Execute(ctx, BranchOptions{Prefix: "feat", Avoid: []string{"feat/add-login"}})
+	if err != nil || len(res.Suggestions) != 1 || res.Suggestions[0].String() != "feat/add-login-flow" {
+		t.Fatalf("branch: %q, %v", res.Suggestions, err)
+	}
+}
+
+type fakeHistory struct {
+	published string
+	reworded  map[string]string
+	head      string
+	commits   []BranchCommit
+	rewritten map[string]string
+}
+
+func (f *fakeHistory) PublishedIn(context.Context, string) (string, error) {
+	return f.published, nil
+}
+
+func (f *fakeHistory) Reword(_ context.Context, rev, message string) (string, error) {
+	if f.reworded == nil {
+		f.reworded = map[string]string{}
+	}
+	f.reworded[rev] = message
+	return "abc123", nil
+}
+
+func (f *fakeHistory) BranchCommits(context.Context, string) (string, []BranchCommit, error) {
+	return f.head, f.commits, nil
+}
+
+func (f *fakeHistory) RewriteMessages(_ context.Context, _, head string, messages map[string]string) (string, error) {
+	if head != f.head {
+		return "", errors.New("branch moved")
+	}
+	f.rewritten = messages
+	return "refs/lazycommit/backup/topic", nil
+}
+
+// perCommitDiffs serves a different diff for each commit, safely for
+// concurrent callers.
+type perCommitDiffs struct {
+	fakeDiffSource
+	diffs map[string]string
+}
+
+func (f *perCommitDiffs) CommitDiff(_ context.Context, rev string, _ []string) (RawDiff, error) {
+	return RawDiff{Patch: f.diffs[rev]}, nil
+}
+
+// echoGenerator turns the diff line of the prompt into a suggestion and
+// records the peak number of concurrent calls.
+type echoGenerator struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (g *echoGenerator) Generate(_ context.Context, p domain.Prompt) (string, error) {
+	g.mu.Lock()
+	g.inFlight++
+	g.maxInFlight = max(g.maxInFlight, g.inFlight)
+	g.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	g.mu.Lock()
+	g.inFlight--
+	g.mu.Unlock()
+	change := strings.TrimPrefix(strings.TrimPrefix(p.User, "COMMIT "), "+")
+	change, _, _ = strings.Cut(change, "\n")
+	return "feat: " + change, nil
+}
+
+func TestRewriteBranchKeepsCommitsWithoutChanges(t *testing.T) {
+	history := &fakeHistory{head: "h2", commits: []BranchCommit{
+		{Hash: "h1", Subject: "chore: trigger CI"},
+		{Hash: "h2", Subject: "wip"},
+	}}
+	diffs := &perCommitDiffs{diffs: map[string]string{"h2": "+add login"}}
+	uc := NewRewriteBranch(NewRewordCommit(&echoGenerator{}, diffs, &fakeConfig{settings: testSettings(t)}, history), history)
+
+	plan, err := uc.Plan(context.Background(), "main", CommitOptions{}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.Rewritten() != 1 || plan.Entries[0].Changed() {
+		t.Fatalf("unexpected plan: %+v", plan.Entries)
+	}
+	if _, err := uc.Apply(context.Background(), plan); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := history.rewritten["h1"]; ok || history.rewritten["h2"] != "feat: add login" {
+		t.Fatalf("rewritten = %v", history.rewritten)
+	}
+}
+
+func TestRewriteBranchPlansConcurrentlyAndKeepsMerges(t *testing.T) {
+	history := &fakeHistory{head: "h4", commits: []BranchCommit{
+		{Hash: "h1", Subject: "wip"},
+		{Hash: "h2", Subject: "wip"},
+		{Hash: "h3", Subject: "Merge branch 'main'", Merge: true},
+		{Hash: "h4", Subject: "wip"},
+	}}
+	diffs := &perCommitDiffs{diffs: map[string]string{"h1": "+add login", "h2": "+add logout", "h4": "+add signup"}}
+	gen := &echoGenerator{}
+	uc := NewRewriteBranch(NewRewordCommit(gen, diffs, &fakeConfig{settings: testSettings(t)}, history), history)
+
+	plan, err := uc.Plan(context.Background(), "main", CommitOptions{}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gen.maxInFlight > 2 {
+		t.Fatalf("concurrency bound exceeded: %d in flight", gen.maxInFlight)
+	}
+	if plan.Rewritten() != 3 || plan.Entries[1].Message.String() != "feat: add logout" || plan.Entries[2].Message.String() != "" {
+		t.Fatalf("unexpected plan: %+v", plan.Entries)
+	}
+
+	backup, err := uc.Apply(context.Background(), plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup != "refs/lazycommit/backup/topic" || len(history.rewritten) != 3 || history.rewritten["h4"] != "feat: add signup" {
+		t.Fatalf("rewritten = %v, backup %q", history.rewritten, backup)
+	}
+	if _, ok := history.rewritten["h3"]; ok {
+		t.Fatal("merge commit was given a new message")
+	}
+}
+
+// fakeBranches accepts any name without "..", like check-ref-format.
+type fakeBranches struct {
+	created string
+}
+
+func (f *fakeBranches) CheckBranchName(_ context.Context, name string) error {
+	if strings.Contains(name, "..") {
+		return errors.New("invalid")
+	}
+	return nil
+}
+
+func (f *fakeBranches) CreateBranch(_ context.Context, name string) error {
+	f.created = name
+	return nil
+}
+
+func TestBranchNamesFromWorkingTreeWithPrefixAndHintTicket(t *testing.T) {
+	gen := &fakeGenerator{output: "Add login page\nfeat/fix..this\nrefactor session store"}
+	diffs := &fakeDiffSource{working: "+unstaged change"}
+	branches := &fakeBranches{}
+	cfg := &fakeConfig{settings: testSettings(t)}
+	uc := NewSuggestBranchNames(gen, diffs, cfg, branches)
+
+	res, err := uc.Execute(context.Background(), BranchOptions{Prefix: "feat", Hint: "for PAY-12"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, s := range res.Suggestions {
+		got = append(got, s.String())
+	}
+	if want := "feat/pay-12-add-login-page|feat/pay-12-fix-this|feat/pay-12-refactor-session-store"; strings.Join(got, "|") != want {
+		t.Fatalf("names = %q", got)
+	}
+	if cfg.loads != 1 {
+		t.Fatalf("settings loaded %d times, want once", cfg.loads)
+	}
+	if !strings.HasPrefix(gen.lastPrompt.User, "BRANCH +unstaged change") {
+		t.Fatalf("working tree diff or template not used: %q", gen.lastPrompt.User)
+	}
+
+	if err := uc.Create(context.Background(), "bad..name"); err == nil || branches.created != "" {
+		t.Fatal("invalid name was created")
+	}
+	if err := uc.Create(context.Background(), got[0]); err != nil || branches.created != got[0] {
+		t.Fatalf("created %q, %v", branches.created, err)
+	}
+}
+
+type fakeCommitLog struct {
+	commits  []LoggedCommit
+	from, to string
+}
+
+func (f *fakeCommitLog) RangeCommits(_ context.Context, from, to string) ([]LoggedCommit, error) {
+	f.from, f.to = from, to
+	return f.commits, nil
+}
+
+func TestChangelogGroupsByTypeAndSkipsChores(t *testing.T) {
+	log := &fakeCommitLog{commits: []LoggedCommit{
+		{Hash: "a", ShortHash: "a1", Message: "feat: add search"},
+		{Hash: "b", ShortHash: "b1", Message: "chore: bump deps"},
+		{Hash: "c", ShortHash: "c1", Message: "fix!: change the error format"},
+		{Hash: "d", ShortHash: "d1", Message: "feat(ui): add dark mode"},
+	}}
+	gen := &scriptedGenerator{outputs: []string{"- Errors are now JSON.", "- Search.\n- Dark mode."}}
+	uc := NewGenerateChangelog(gen, &fakeDiffSource{commit: "+x"}, &fakeConfig{settings: testSettings(t)}, log)
+
+	res, err := uc.Execute(context.Background(), "v1.0.0..", ChangelogOptions{Version: "1.1.0", Date: "2026-10-17"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if log.from != "v1.0.0" || log.to != "HEAD" {
+		t.Fatalf("range = %q..%q", log.from, log.to)
+	}
+	want := "## [1.1.0] - 2026-10-17\n\n### Breaking Changes\n\n- Errors are now JSON.\n\n### Added\n\n- Search.\n- Dark mode.\n"
+	if res.Markdown != want {
+		t.Fatalf("markdown = %q", res.Markdown)
+	}
+	if len(gen.prompts) != 2 || !strings.Contains(gen.prompts[1].User, "Section: Added") ||
+		!strings.Contains(gen.prompts[1].User, "commit d1\nfeat(ui): add dark mode") || strings.Contains(gen.prompts[1].User, "bump deps") {
+		t.Fatalf("unexpected prompts: %+v", gen.prompts)
+	}
+
+	if _, err := uc.Execute(context.Background(), "a...b", ChangelogOptions{}); err == nil {
+		t.Fatal("expected a symmetric range to be rejected")
+	}
+}
+
+func TestTicketedHistoryReadsAsConventional(t *testing.T) {
+	settings := testSettings(t)
+	settings.TicketFormat = "%s - "
+	log := &fakeCommitLog{commits: []LoggedCommit{
+		{Hash: "a", ShortHash: "a1", Message: "PAY-7 - feat: add refunds"},
+		{Hash: "b", ShortHash: "b1", Message: "PAY-8 - chore: bump deps"},
+	}}
+	gen := &scriptedGenerator{outputs: []string{"- Refunds."}}
+	changelog := NewGenerateChangelog(gen, &fakeDiffSource{commit: "+x"},
+		&fakeConfig{settings: settings}, log)
+
+	res, err := changelog.Execute(context.Background(), "v1.0.0", ChangelogOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(gen.prompts) != 1 || !strings.Contains(res.Markdown, "### Added\n\n- Refunds.") {
+		t.Fatalf("markdown = %q", res.Markdown)
+	}
+
+	bump := NewRecommendBump(&scriptedGenerator{}, &fakeDiffSource{commit: "+x"},
+		&fakeConfig{settings: settings}, log, &fakeTagger{})
+	plan, err := bump.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.Impact != domain.ImpactMinor || plan.Commits[0].FromModel {
+		t.Fatalf("ticketed history went to the model: %+v", plan)
+	}
+}
+
+type fakeTagger struct {
+	tags    []string
+	name    string
+	message string
+}
+
+func (f *fakeTagger) ReleaseTags(context.Context) ([]string, error) {
+	return f.tags, nil
+}
+
+func (f *fakeTagger) CreateTag(_ context.Context, name, message string) error {
+	f.name, f.message = name, message
+	return nil
+}
+
+func TestBumpClassifiesAndFallsBackToModel(t *testing.T) {
+	log := &fakeCommitLog{commits: []LoggedCommit{
+		{Hash: "a", ShortHash: "a1", Message: "fix: guard nil"},
+		{Hash: "b", ShortHash: "b1", Message: "wip"},
+		{Hash: "c", ShortHash: "c1", Message: "docs: typo"},
+	}}
+	tags := &fakeTagger{tags: []string{"v1.2.3", "v1.10.0", "latest"}}
+	gen := &scriptedGenerator{outputs: []string{"minor: adds an export command", "- Export command.\n- Nil guard."}}
+	uc := NewRecommendBump(gen, &fakeDiffSource{commit: "+export"}, &fakeConfig{settings: testSettings(t)}, log, tags)
+
+	plan, err := uc.Plan(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if log.from != "v1.10.0" || plan.Current != "v1.10.0" || plan.Next.String() != "v1.11.0" || plan.Impact != domain.ImpactMinor {
+		t.Fatalf("unexpected plan: from %q, %+v", log.from, plan)
+	}
+	if len(gen.prompts) != 1 || !strings.Contains(gen.prompts[0].User, "wip") || !plan.Commits[1].FromModel ||
+		plan.Commits[1].Reason != "adds an export command" || plan.Commits[0].FromModel {
+		t.Fatalf("only the non-conventional commit should reach the model: %+v", plan.Commits)
+	}
+
+	name, err := uc.Tag(context.Background(), plan, "2026-10-17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "v1.11.0" || !strings.HasPrefix(tags.message, "Release v1.11.0\n\n## [v1.11.0] - 2026-10-17\n") {
+		t.Fatalf("tag %q, message %q", name, tags.message)
+	}
+}
+
+func TestRewordSuggestsFromCommitDiffAndRefusesPublished(t *testing.T) {
+	gen := &fakeGenerator{output: "feat: one"}
+	diffs := &fakeDiffSource{commit: "+commit change"}
+	history := &fakeHistory{published: "origin/main"}
+	settings := testSettings(t)
+	settings.HistoryExamples = 5
+	uc := NewRewordCommit(gen, diffs, &fakeConfig{settings: settings}, history)
+
+	res, err := uc.Suggest(context.Background(), "HEAD~2", CommitOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diffs.lastTarget != "HEAD~2" || !strings.HasPrefix(gen.lastPrompt.User, "COMMIT +commit change") {
+		t.Fatalf("commit diff or template not used: %q %q", diffs.lastTarget, gen.lastPrompt.User)
+	}
+	if len(diffs.historyCalls) != 0 {
+		t.Fatal("the history being reworded must not be offered as style examples")
+	}
+	if _, err := uc.Apply(context.Background(), "HEAD~2", res.Suggestions[0]); err == nil ||
+		!strings.Contains(err.Error(), "already pushed to origin/main") {
+		t.Fatalf("expected refusal, got %v", err)
+	}
+	if len(history.reworded) != 0 {
+		t.Fatal("published commit was rewritten")
+	}
+
+	history.published = ""
+	if _, err := uc.Apply(context.Background(), "HEAD~2", res.Suggestions[0]); err != nil {
+		t.Fatal(err)
+	}
+	if history.reworded["HEAD~2"] != "feat: one" {
+		t.Fatalf("reworded = %v", history.reworded)
+	}
+}
@@ -6,8 +6,11 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -17,8 +20,8 @@ import (
 )
 
 const (
-	// DefaultBackend is the only backend family lazycommit talks to: any
-	// endpoint speaking the OpenAI chat-completions protocol.
+	// DefaultBackend is used until another is chosen: any endpoint speaking
+	// the OpenAI chat-completions protocol.
 	DefaultBackend = "openai-compatible"
 
 	backendsFile    = "config.yaml"
@@ -35,6 +38,45 @@ type BackendSettings struct {
 	FallbackModels []string `yaml:"fallback_models,omitempty"`
 	APIKey         string   `yaml:"api_key,omitempty"`
 	BaseURL        string   `yaml:"base_url,omitempty"`
+	// APIVersion is the Azure OpenAI api-version; empty uses the backend's
+	// default.
+	APIVersion string `yaml:"api_version,omitempty"`
+	// APIKeyInQuery sends Gemini's key as the key query parameter instead
+	// of a header, for proxies that expect it there.
+	APIKeyInQuery bool `yaml:"api_key_in_query,omitempty"`
+	// Command is the program and arguments the exec backend runs, and
+	// InputFormat how it receives the prompt on stdin ("json" or "text").
+	Command     []string `yaml:"command,omitempty"`
+	InputFormat string   `yaml:"input_format,omitempty"`
+	// NumCtx sets Ollama's context length and KeepAlive how long it keeps
+	// the model loaded ("10m"); unset values keep Ollama's defaults.
+	NumCtx    int    `yaml:"num_ctx,omitempty"`
+	KeepAlive string `yaml:"keep_alive,omitempty"`
+	// MaxDiffTokens caps the estimated size of the diff sent to any model of
+	// this backend; ModelMaxDiffTokens overrides it per model. Zero means
+	// unlimited.
+	MaxDiffTokens      int            `yaml:"max_diff_tokens,omitempty"`
+	ModelMaxDiffTokens map[string]int `yaml:"model_max_diff_tokens,omitempty"`
+}
+
+// DiffTokenBudget is the smallest diff budget across the primary and
+// fallback models, since the prompt is built once before the fallback chain
+// runs and must fit whichever model ends up answering. Zero means unlimited.
+func (s BackendSettings) DiffTokenBudget() int {
+	budget := 0
+	for _, model := range append([]string{s.Model}, s.FallbackModels...) {
+		if model == "" {
+			continue
+		}
+		limit := s.MaxDiffTokens
+		if override, ok := s.ModelMaxDiffTokens[model]; ok {
+			limit = override
+		}
+		if limit > 0 && (budget == 0 || limit < budget) {
+			budget = limit
+		}
+	}
+	return budget
 }
 
 // Backends is the secret half of the configuration (global only).
@@ -48,8 +90,52 @@ type Prompts struct {
 	Language              string `yaml:"language,omitempty"`
 	SystemMessage         string `yaml:"system_message,omitempty"`
 	CommitMessageTemplate string `yaml:"commit_message_template,omitempty"`
+	CommitBodyTemplate    string `yaml:"commit_body_template,omitempty"`
+	SquashMessageTemplate string `yaml:"squash_message_template,omitempty"`
 	PRTitleTemplate       string `yaml:"pr_title_template,omitempty"`
+	PRBodyTemplate        string `yaml:"pr_body_template,omitempty"`
+	BranchNameTemplate    string `yaml:"branch_name_template,omitempty"`
+	ChangelogTemplate     string `yaml:"changelog_template,omitempty"`
 	NumSuggestions        int    `yaml:"num_suggestions,omitempty"`
+	// LargeDiffStrategy is "truncate" (default) or "map-reduce".
+	LargeDiffStrategy  string `yaml:"large_diff_strategy,omitempty"`
+	SummaryConcurrency int    `yaml:"summary_concurrency,omitempty"`
+	// ExcludePaths replaces DefaultExcludePaths when set.
+	ExcludePaths []string `yaml:"exclude_paths,omitempty"`
+	// HistoryExamples enables recent commit subjects as style examples;
+	// HistoryScope is "repository" (default) or "paths".
+	HistoryExamples int    `yaml:"history_examples,omitempty"`
+	HistoryScope    string `yaml:"history_scope,omitempty"`
+	// TicketPattern is a regular expression matched against the branch
+	// name; its first capture group (or whole match) prefixes every commit
+	// suggestion, rendered through TicketFormat.
+	TicketPattern string `yaml:"ticket_pattern,omitempty"`
+	TicketFormat  string `yaml:"ticket_format,omitempty"`
+	// Scopes maps path globs to conventional-commit scopes.
+	Scopes map[string]string `yaml:"scopes,omitempty"`
+	// CommitStyle is "free" (default) or "conventional".
+	CommitStyle string `yaml:"commit_style,omitempty"`
+	// LintRules uses commitlint's rule syntax, [level, applicability,
+	// value]; layers merge rule by rule.
+	LintRules map[string][]any `yaml:"lint_rules,omitempty"`
+}
+
+// DefaultExcludePaths keeps lockfiles, snapshots, and generated code out of
+// the prompt; they dominate diffs while saying little about intent.
+var DefaultExcludePaths = []string{
+	"**/go.sum",
+	"**/package-lock.json",
+	"**/pnpm-lock.yaml",
+	"**/yarn.lock",
+	"**/Cargo.lock",
+	"**/poetry.lock",
+	"**/Gemfile.lock",
+	"**/composer.lock",
+	"**/*.pb.go",
+	"**/*_pb2.py",
+	"**/*.min.js",
+	"**/*.snap",
+	"**/__snapshots__/**",
 }
 
 // DefaultBackends is the effective configuration when no file exists; the
@@ -164,6 +250,44 @@ func (r *Repository) LoadPrompts() (Prompts, error) {
 	return mergePrompts(local, global), nil
 }
 
+// prTemplatePaths are where GitHub looks for a repository's pull request
+// template, relative to the repository root.
+var prTemplatePaths = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"docs/pull_request_template.md",
+}
+
+// PRLayout implements app.ConfigRepository: the first pull request
+// template found in the working repository, or "".
+func (r *Repository) PRLayout() (string, error) {
+	if r.repoRoot == "" {
+		return "", nil
+	}
+	for _, rel := range prTemplatePaths {
+		data, err := os.ReadFile(filepath.Join(r.repoRoot, rel))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading %s: %w", rel, err)
+		}
+		return string(data), nil
+	}
+	return "", nil
+}
+
+// DiffTokenBudget implements app.ConfigRepository: the active backend's
+// diff budget across its model chain.
+func (r *Repository) DiffTokenBudget() (int, error) {
+	backends, err := r.LoadBackendsRaw()
+	if err != nil {
+		return 0, err
+	}
+	return backends.Backends[backends.Active].DiffTokenBudget(), nil
+}
+
 // PromptSettings implements app.ConfigRepository: the fully layered,
 // validated effective settings.
 func (r *Repository) PromptSettings() (app.PromptSettings, error) {
@@ -181,6 +305,24 @@ func (r *Repository) PromptSettings() (app.PromptSettings, error) {
 		return app.PromptSettings{}, fmt.Errorf("commit_message_template: %w", err)
 	}
 
+	bodyText := p.CommitBodyTemplate
+	if bodyText == "" {
+		bodyText = domain.DefaultCommitBodyTemplate
+	}
+	body, err := domain.NewPromptTemplate(bodyText)
+	if err != nil {
+		return app.PromptSettings{}, fmt.Errorf("commit_body_template: %w", err)
+	}
+
+	squashText := p.SquashMessageTemplate
+	if squashText == "" {
+		squashText = domain.DefaultSquashTemplate
+	}
+	squash, err := domain.NewPromptTemplate(squashText)
+	if err != nil {
+		return app.PromptSettings{}, fmt.Errorf("squash_message_template: %w", err)
+	}
+
 	prText := p.PRTitleTemplate
 	if prText == "" {
 		prText = domain.DefaultPRTitleTemplate
@@ -190,6 +332,30 @@ func (r *Repository) PromptSettings() (app.PromptSettings, error) {
 		return app.PromptSettings{}, fmt.Errorf("pr_title_template: %w", err)
 	}
 
+	prBodyText := p.PRBodyTemplate
+	if prBodyText == "" {
+		prBodyText = domain.DefaultPRBodyTemplate
+	}
+	prBody, err := domain.NewPromptTemplate(prBodyText)
+	if err != nil {
+		return app.PromptSettings{}, fmt.Errorf("pr_body_template: %w", err)
+	}
+	branchText := p.BranchNameTemplate
+	if branchText == "" {
+		branchText = domain.DefaultBranchTemplate
+	}
+	branch, err := domain.NewPromptTemplate(branchText)
+	if err != nil {
+		return app.PromptSettings{}, fmt.Errorf("branch_name_template: %w", err)
+	}
+	changelogText := p.ChangelogTemplate
+	if changelogText == "" {
+		changelogText = domain.DefaultChangelogTemplate
+	}
+	changelog, err := domain.NewPromptTemplate(changelogText)
+	if err != nil {
+		return app.PromptSettings{}, fmt.Errorf("changelog_template: %w", err)
+	}
 	system := p.SystemMessage
 	if system == "" {
 		system = domain.DefaultSystemMessage
@@ -199,15 +365,145 @@ func (r *Repository) PromptSettings() (app.PromptSettings, error) {
 		count = domain.DefaultSuggestionCount
 	}
 
+	strategy := app.DiffStrategy(p.LargeDiffStrategy)
+	switch strategy {
+	case "":
+		strategy = app.DiffStrategyTruncate
+	case app.DiffStrategyTruncate, app.DiffStrategyMapReduce:
+	default:
+		return app.PromptSettings{}, fmt.Errorf(
+			"large_diff_strategy: unknown strategy %q (use %s or %s)",
+			p.LargeDiffStrategy, app.DiffStrategyTruncate, app.DiffStrategyMapReduce)
+	}
+	concurrency := p.SummaryConcurrency
+	if concurrency <= 0 {
+		concurrency = app.DefaultSummaryConcurrency
+	}
+	exclude := p.ExcludePaths
+	if len(exclude) == 0 {
+		exclude = DefaultExcludePaths
+	}
+
+	scope := app.HistoryScope(p.HistoryScope)
+	switch scope {
+	case "":
+		scope = app.HistoryScopeRepository
+	case app.HistoryScopeRepository, app.HistoryScopePaths:
+	default:
+		return app.PromptSettings{}, fmt.Errorf(
+			"history_scope: unknown scope %q (use %s or %s)",
+			p.HistoryScope, app.HistoryScopeRepository, app.HistoryScopePaths)
+	}
+
+	var ticketPattern *regexp.Regexp
+	if p.TicketPattern != "" {
+		ticketPattern, err = regexp.Compile(p.TicketPattern)
+		if err != nil {
+			return app.PromptSettings{}, fmt.Errorf("ticket_pattern: %w", err)
+		}
+	}
+	ticketFormat := p.TicketFormat
+	if ticketFormat == "" {
+		ticketFormat = domain.DefaultTicketFormat
+	}
+	if err := domain.ValidateTicketFormat(ticketFormat); err != nil {
+		return app.PromptSettings{}, fmt.Errorf("ticket_format: %w", err)
+	}
+	scopes, err := domain.NewScopeMap(p.Scopes)
+	if err != nil {
+		return app.PromptSettings{}, fmt.Errorf("scopes: %w", err)
+	}
+	style := app.CommitStyle(p.CommitStyle)
+	switch style {
+	case "":
+		style = app.CommitStyleFree
+	case app.CommitStyleFree, app.CommitStyleConventional:
+	default:
+		return app.PromptSettings{}, fmt.Errorf(
+			"commit_style: unknown style %q (use %s or %s)",
+			p.CommitStyle, app.CommitStyleFree, app.CommitStyleConventional)
+	}
+
+	specs, err := lintRuleSpecs(p.LintRules)
+	if err != nil {
+		return app.PromptSettings{}, fmt.Errorf("lint_rules: %w", err)
+	}
+	lint, err := domain.NewLintRules(specs)
+	if err != nil {
+		return app.PromptSettings{}, fmt.Errorf("lint_rules: %w", err)
+	}
+
 	return app.PromptSettings{
-		SystemMessage:   system,
-		CommitTemplate:  commit,
-		PRTitleTemplate: pr,
-		Language:        domain.NewLanguage(p.Language),
-		SuggestionCount: count,
+		SystemMessage:      system,
+		CommitTemplate:     commit,
+		CommitBodyTemplate: body,
+		SquashTemplate:     squash,
+		PRTitleTemplate:    pr,
+		PRBodyTemplate:     prBody,
+		BranchTemplate:     branch,
+		ChangelogTemplate:  changelog,
+		Language:           domain.NewLanguage(p.Language),
+		SuggestionCount:    count,
+		DiffStrategy:       strategy,
+		SummaryConcurrency: concurrency,
+		ExcludePaths:       exclude,
+		HistoryExamples:    p.HistoryExamples,
+		HistoryScope:       scope,
+		TicketPattern:      ticketPattern,
+		TicketFormat:       ticketFormat,
+		Scopes:             scopes,
+		CommitStyle:        style,
+		LintRules:          lint,
 	}, nil
 }
 
+// lintRuleSpecs converts commitlint-style entries such as
+// `header-max-length: [2, always, 72]` into rule specs, sorted by name.
+func lintRuleSpecs(raw map[string][]any) ([]domain.RuleSpec, error) {
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var specs []domain.RuleSpec
+	for _, name := range names {
+		entry := raw[name]
+		if len(entry) == 0 || len(entry) > 3 {
+			return nil, fmt.Errorf("%s: want [level, applicability, value]", name)
+		}
+		level, ok := entry[0].(int)
+		if !ok {
+			return nil, fmt.Errorf("%s: level %v is not 0, 1, or 2", name, entry[0])
+		}
+		spec := domain.RuleSpec{Name: name, Level: domain.RuleLevel(level)}
+		if len(entry) > 1 {
+			switch entry[1] {
+			case "always":
+			case "never":
+				spec.Never = true
+			default:
+				return nil, fmt.Errorf(
+					"%s: applicability %v is not always or never", name, entry[1])
+			}
+		}
+		if len(entry) > 2 {
+			spec.Value = entry[2]
+			if list, ok := entry[2].([]any); ok {
+				values := make([]string, len(list))
+				for i, v := range list {
+					if values[i], ok = v.(string); !ok {
+						return nil, fmt.Errorf("%s: value %v is not a string", name, v)
+					}
+				}
+				spec.Value = values
+			}
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
 func (r *Repository) expandSecret(value string) (string, error) {
 	if !strings.HasPrefix(value, "$") {
 		return value, nil
@@ -232,12 +528,60 @@ func mergePrompts(top, bottom Prompts) Prompts {
 	if top.CommitMessageTemplate != "" {
 		out.CommitMessageTemplate = top.CommitMessageTemplate
 	}
+	if top.CommitBodyTemplate != "" {
+		out.CommitBodyTemplate = top.CommitBodyTemplate
+	}
+	if top.SquashMessageTemplate != "" {
+		out.SquashMessageTemplate = top.SquashMessageTemplate
+	}
 	if top.PRTitleTemplate != "" {
 		out.PRTitleTemplate = top.PRTitleTemplate
 	}
+	if top.PRBodyTemplate != "" {
+		out.PRBodyTemplate = top.PRBodyTemplate
+	}
+	if top.BranchNameTemplate != "" {
+		out.BranchNameTemplate = top.BranchNameTemplate
+	}
+	if top.ChangelogTemplate != "" {
+		out.ChangelogTemplate = top.ChangelogTemplate
+	}
 	if top.NumSuggestions > 0 {
 		out.NumSuggestions = top.NumSuggestions
 	}
+	if top.LargeDiffStrategy != "" {
+		out.LargeDiffStrategy = top.LargeDiffStrategy
+	}
+	if top.SummaryConcurrency > 0 {
+		out.SummaryConcurrency = top.SummaryConcurrency
+	}
+	if len(top.ExcludePaths) > 0 {
+		out.ExcludePaths = top.ExcludePaths
+	}
+	if top.HistoryExamples > 0 {
+		out.HistoryExamples = top.HistoryExamples
+	}
+	if top.HistoryScope != "" {
+		out.HistoryScope = top.HistoryScope
+	}
+	if top.TicketPattern != "" {
+		out.TicketPattern = top.TicketPattern
+	}
+	if top.TicketFormat != "" {
+		out.TicketFormat = top.TicketFormat
+	}
+	if len(top.Scopes) > 0 {
+		out.Scopes = top.Scopes
+	}
+	if top.CommitStyle != "" {
+		out.CommitStyle = top.CommitStyle
+	}
+	if len(top.LintRules) > 0 {
+		merged := make(map[string][]any, len(bottom.LintRules)+len(top.LintRules))
+		maps.Copy(merged, bottom.LintRules)
+		maps.Copy(merged, top.LintRules)
+		out.LintRules = merged
+	}
 	return out
 }
 
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/m7medvision/lazycommit/internal/app"
 	"github.com/m7medvision/lazycommit/internal/domain"
 )
 
@@ -186,3 +187,234 @@ func TestPromptsRoundTrip(t *testing.T) {
 		t.Fatalf("round trip mangled prompts: %+v", p)
 	}
 }
+
+func TestDiffTokenBudgetTakesSmallestAcrossChain(t *testing.T) {
+	s := BackendSettings{
+		Model:              "big",
+		FallbackModels:     []string{"small", "unlimited"},
+		MaxDiffTokens:      8000,
+		ModelMaxDiffTokens: map[string]int{"small": 2000, "unlimited": 0},
+	}
+	if got := s.DiffTokenBudget(); got != 2000 {
+		t.Fatalf("budget = %d, want 2000", got)
+	}
+	if got := (BackendSettings{Model: "m"}).DiffTokenBudget(); got != 0 {
+		t.Fatalf("unset budget should be unlimited, got %d", got)
+	}
+}
+
+func TestRepositoryDiffTokenBudgetFromActiveBackend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "lazycommit")
+	writeFile(t, filepath.Join(dir, "config.yaml"), `
+active_backend: openai-compatible
+backends:
+  openai-compatible:
+    model: llama3.1:8b
+    max_diff_tokens: 6000
+    model_max_diff_tokens:
+      llama3.1:8b: 3000
+`)
+	budget, err := NewRepository(dir, "").DiffTokenBudget()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget != 3000 {
+		t.Fatalf("max diff tokens = %d, want 3000", budget)
+	}
+}
+
+func TestPromptSettingsDiffStrategy(t *testing.T) {
+	globalDir := filepath.Join(t.TempDir(), "lazycommit")
+	s, err := NewRepository(globalDir, "").PromptSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.DiffStrategy != app.DiffStrategyTruncate || s.SummaryConcurrency != app.DefaultSummaryConcurrency {
+		t.Fatalf("unexpected defaults: %q %d", s.DiffStrategy, s.SummaryConcurrency)
+	}
+
+	writeFile(t, filepath.Join(globalDir, "prompts.yaml"), "large_diff_strategy: map-reduce\nsummary_concurrency: 2\n")
+	s, err = NewRepository(globalDir, "").PromptSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.DiffStrategy != app.DiffStrategyMapReduce || s.SummaryConcurrency != 2 {
+		t.Fatalf("strategy not loaded: %q %d", s.DiffStrategy, s.SummaryConcurrency)
+	}
+
+	writeFile(t, filepath.Join(globalDir, "prompts.yaml"), "large_diff_strategy: shred\n")
+	if _, err := NewRepository(globalDir, "").PromptSettings(); err == nil || !strings.Contains(err.Error(), "large_diff_strategy") {
+		t.Fatalf("expected strategy validation error, got %v", err)
+	}
+}
+
+func TestPromptSettingsExcludePaths(t *testing.T) {
+	globalDir := filepath.Join(t.TempDir(), "lazycommit")
+	repoRoot := t.TempDir()
+	s, err := NewRepository(globalDir, repoRoot).PromptSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(s.ExcludePaths, ",") != strings.Join(DefaultExcludePaths, ",") {
+		t.Fatalf("expected built-in exclusions, got %v", s.ExcludePaths)
+	}
+
+	writeFile(t, filepath.Join(repoRoot, "lazycommit.prompts.yaml"), "exclude_paths:\n  - \"gen/**\"\n")
+	s, err = NewRepository(globalDir, repoRoot).PromptSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(s.ExcludePaths, ",") != "gen/**" {
+		t.Fatalf("repo exclusions should replace defaults, got %v", s.ExcludePaths)
+	}
+}
+
+func TestPromptSettingsHistoryExamples(t *testing.T) {
+	globalDir := filepath.Join(t.TempDir(), "lazycommit")
+	writeFile(t, filepath.Join(globalDir, "prompts.yaml"), "history_examples: 8\nhistory_scope: paths\n")
+	s, err := NewRepository(globalDir, "").PromptSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.HistoryExamples != 8 || s.HistoryScope != app.HistoryScopePaths {
+		t.Fatalf("history settings not loaded: %d %q", s.HistoryExamples, s.HistoryScope)
+	}
+
+	writeFile(t, filepath.Join(globalDir, "prompts.yaml"), "history_scope: everything\n")
+	if _, err := NewRepository(globalDir, "").PromptSettings(); err == nil || !strings.Contains(err.Error(), "history_scope") {
+		t.Fatalf("expected scope validation error, got %v", err)
+	}
+}
+
+func TestPromptSettingsTicketPattern(t *testing.T) {
+	globalDir := filepath.Join(t.TempDir(), "lazycommit")
+	s, err := NewRepository(globalDir, "").PromptSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.TicketPattern != nil || s.TicketFormat != domain.DefaultTicketFormat {
+		t.Fatalf("tickets should be off by default: %v %q", s.TicketPattern, s.TicketFormat)
+	}
+
+	writeFile(t, filepath.Join(globalDir, "prompts.yaml"), "ticket_pattern: '([A-Z]+-\\d+)'\nticket_format: '[%s] '\n")
+	s, err = NewRepository(globalDir, "").PromptSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.TicketPattern == nil || !s.TicketPattern.MatchString("feature/PAY-1") || s.TicketFormat != "[%s] " {
+		t.Fatalf("ticket settings not loaded: %v %q", s.TicketPattern, s.TicketFormat)
+	}
+
+	writeFile(t, filepath.Join(globalDir, "prompts.yaml"), "ticket_pattern: '(['\n")
+	if _, err := NewRepository(globalDir, "").PromptSettings(); err == nil || !strings.Contains(err.Error(), "ticket_pattern") {
+		t.Fatalf("expected regex error, got %v", err)
+	}
+	writeFile(t, filepath.Join(globalDir, "prompts.yaml"), "ticket_format: 'no placeholder'\n")
+	if _, err := NewRepository(globalDir, "").PromptSettings(); err == nil || !strings.Contains(err.Error(), "ticket_format") {
+		t.Fatalf("expected format error, got %v", err)
+	}
+}
+
+func TestRepositoryPRLayout(t *testing.T) {
+	globalDir := filepath.Join(t.TempDir(), "lazycommit")
+	repoRoot := t.TempDir()
+	layout, err := NewRepository(globalDir, repoRoot).PRLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout != "" {
+		t.Fatalf("unexpected default layout %q", layout)
+	}
+
+	writeFile(t, filepath.Join(repoRoot, ".github", "pull_request_template.md"), "## What\n\n## Checklist\n- [ ] tests\n")
+	layout, err = NewRepository(globalDir, repoRoot).PRLayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout != "## What\n\n## Checklist\n- [ ] tests\n" {
+		t.Fatalf("layout = %q", layout)
+	}
+}
+
+func TestPromptSettingsIgnoresUnreadablePRTemplate(t *testing.T) {
+	globalDir := filepath.Join(t.TempDir(), "lazycommit")
+	repoRoot := t.TempDir()
+	// A directory in the template's place cannot be read as a file.
+	if err := os.MkdirAll(filepath.Join(repoRoot, ".github", "pull_request_template.md"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewRepository(globalDir, repoRoot)
+	s, err := repo.PromptSettings()
+	if err != nil {
+		t.Fatalf("prompt settings should not read the PR template: %v", err)
+	}
+	if !strings.Contains(s.PRBodyTemplate.String(), "## Testing") {
+		t.Fatalf("unexpected default template %q", s.PRBodyTemplate)
+	}
+	if _, err := repo.PRLayout(); err == nil {
+		t.Fatal("expected an error reading the PR template")
+	}
+}
+
+func TestPromptSettingsScopes(t *testing.T) {
+	globalDir := filepath.Join(t.TempDir(), "lazycommit")
+	repoRoot := t.TempDir()
+	writeFile(t, filepath.Join(repoRoot, "lazycommit.prompts.yaml"), `
+scopes:
+  "services/billing/**": billing
+  "web/**": web
+`)
+	s, err := NewRepository(globalDir, repoRoot).PromptSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(s.Scopes.Allowed(), ","); got != "billing,web" {
+		t.Fatalf("scopes not loaded: %q", got)
+	}
+}
+
+func TestPromptSettingsCommitStyle(t *testing.T) {
+	globalDir := filepath.Join(t.TempDir(), "lazycommit")
+	s, err := NewRepository(globalDir, "").PromptSettings()
+	if err != nil || s.CommitStyle != app.CommitStyleFree {
+		t.Fatalf("default style = %q, %v", s.CommitStyle, err)
+	}
+	writeFile(t, filepath.Join(globalDir, "prompts.yaml"), "commit_style: conventional\n")
+	s, err = NewRepository(globalDir, "").PromptSettings()
+	if err != nil || s.CommitStyle != app.CommitStyleConventional {
+		t.Fatalf("style = %q, %v", s.CommitStyle, err)
+	}
+	writeFile(t, filepath.Join(globalDir, "prompts.yaml"), "commit_style: gitmoji\n")
+	if _, err := NewRepository(globalDir, "").PromptSettings(); err == nil || !strings.Contains(err.Error(), "commit_style") {
+		t.Fatalf("expected style validation error, got %v", err)
+	}
+}
+
+func TestPromptSettingsLintRulesMergePerRule(t *testing.T) {
+	globalDir := filepath.Join(t.TempDir(), "lazycommit")
+	repoRoot := t.TempDir()
+	writeFile(t, filepath.Join(globalDir, "prompts.yaml"), `
+lint_rules:
+  type-enum: [2, always, [feat, fix]]
+  header-max-length: [2, always, 50]
+`)
+	writeFile(t, filepath.Join(repoRoot, "lazycommit.prompts.yaml"), `
+lint_rules:
+  header-max-length: [0]
+  subject-full-stop: [2, never, "."]
+`)
+	s, err := NewRepository(globalDir, repoRoot).PromptSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(s.LintRules.Describe(), "|")
+	want := `the type must be one of: feat, fix|the subject must not end with "."`
+	if got != want {
+		t.Fatalf("rules = %q, want %q", got, want)
+	}
+
+	writeFile(t, filepath.Join(repoRoot, "lazycommit.prompts.yaml"), "lint_rules:\n  body-leading-blank: [2, always]\n")
+	if _, err := NewRepository(globalDir, repoRoot).PromptSettings(); err == nil || !strings.Contains(err.Error(), "lint_rules") {
+		t.Fatalf("expected unknown rule error, got %v", err)
+	}
+}
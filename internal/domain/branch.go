@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// MaxBranchSlugLength bounds the descriptive, kebab-case part of a
+// suggested branch name.
+const MaxBranchSlugLength = 50
+
+// DefaultTicketPattern finds an issue key such as PAY-1234 in free text
+// when no ticket_pattern is configured.
+var DefaultTicketPattern = regexp.MustCompile(`\b([A-Z][A-Z0-9]+-[0-9]+)\b`)
+
+// BranchSlug turns a suggested branch name into kebab-case: lower-case
+// letters and digits joined by single hyphens, cut at a word boundary to
+// MaxBranchSlugLength. Anything up to the last slash ("feat/") and a
+// leading copy of ticket are dropped, since both are added back by
+// FormatBranchName.
+func BranchSlug(name string, ticket Ticket) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if key := ticket.String(); key != "" {
+		keyWords := strings.FieldsFunc(strings.ToLower(key), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) > len(keyWords) &&
+			slices.Equal(words[:len(keyWords)], keyWords) {
+			words = words[len(keyWords):]
+		}
+	}
+
+	var slug strings.Builder
+	for _, w := range words {
+		if slug.Len() > 0 && slug.Len()+1+len(w) > MaxBranchSlugLength {
+			break
+		}
+		if slug.Len() > 0 {
+			slug.WriteByte('-')
+		}
+		slug.WriteString(w)
+	}
+	return slug.String()
+}
+
+// FormatBranchName assembles prefix ("feat" or "feat/"), the ticket key,
+// lower-cased to stay kebab-case, and slug into "feat/pay-12-add-login".
+// The prefix and ticket are optional; an empty slug gives "".
+func FormatBranchName(prefix string, ticket Ticket, slug string) string {
+	if slug == "" {
+		return ""
+	}
+	if key := ticket.String(); key != "" {
+		slug = strings.ToLower(key) + "-" + slug
+	}
+	if prefix = strings.Trim(strings.TrimSpace(prefix), "/"); prefix != "" {
+		return prefix + "/" + slug
+	}
+	return slug
+}
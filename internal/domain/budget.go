@@ -0,0 +1,202 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// approxCharsPerToken is the estimate used in place of a model-specific
+// tokenizer; code and English prose average about four bytes per token
+// across the common BPE vocabularies.
+const approxCharsPerToken = 4
+
+const (
+	omissionNoteHeader = "\n[lazycommit] Parts of this diff were omitted " +
+		"to fit the model's context window:\n"
+	contextNote = "- unchanged context lines were removed from all hunks\n"
+)
+
+// EstimateTokens approximates how many tokens text occupies.
+func EstimateTokens(text string) int {
+	return estimateBytes(len(text))
+}
+
+func estimateBytes(n int) int {
+	return (n + approxCharsPerToken - 1) / approxCharsPerToken
+}
+
+// FitDiff shrinks d until its estimated size is within maxTokens, or
+// returns it unchanged when it already fits or maxTokens is not positive.
+// It first drops unchanged context lines, then whole hunks from the largest
+// files, then whole files, and appends a per-file note of what was omitted
+// so the model still knows those changes exist.
+func FitDiff(d Diff, maxTokens int) Diff {
+	if maxTokens <= 0 || EstimateTokens(d.text) <= maxTokens {
+		return d
+	}
+	limit := maxTokens * approxCharsPerToken
+
+	var files []*budgetFile
+	for _, f := range d.Files() {
+		bf := &budgetFile{FileDiff: f, totalHunks: len(f.Hunks)}
+		for i, h := range bf.Hunks {
+			bf.Hunks[i] = stripContext(h)
+		}
+		files = append(files, bf)
+	}
+
+	b := budget{files: files}
+	b.recompute()
+
+	for b.size > limit {
+		hasHunks := func(f *budgetFile) bool { return len(f.Hunks) > 0 }
+		if f := b.largest(hasHunks); f != nil {
+			b.dropLastHunk(f)
+			continue
+		}
+		if f := b.largest(func(f *budgetFile) bool { return !f.dropped }); f != nil {
+			b.dropFile(f)
+			continue
+		}
+		break
+	}
+
+	text := b.render()
+	if len(text) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	return Diff{text: text}
+}
+
+type budgetFile struct {
+	FileDiff
+	totalHunks int
+	omitted    int
+	added      int
+	removed    int
+	dropped    bool
+}
+
+func (f *budgetFile) bodySize() int {
+	if f.dropped {
+		return 0
+	}
+	return len(f.String())
+}
+
+func (f *budgetFile) note() string {
+	switch {
+	case f.dropped:
+		return fmt.Sprintf("- %s: file omitted (+%d -%d lines)\n",
+			f.displayPath(), f.added, f.removed)
+	case f.omitted > 0:
+		return fmt.Sprintf("- %s: %d of %d hunks omitted (+%d -%d lines)\n",
+			f.displayPath(), f.omitted, f.totalHunks, f.added, f.removed)
+	}
+	return ""
+}
+
+func (f *budgetFile) displayPath() string {
+	if f.Path == "" {
+		return "(preamble)"
+	}
+	return f.Path
+}
+
+// budget tracks the rendered size incrementally so shrinking a diff with
+// thousands of hunks stays linear per step.
+type budget struct {
+	files []*budgetFile
+	size  int
+}
+
+func (b *budget) recompute() {
+	b.size = len(omissionNoteHeader) + len(contextNote)
+	for _, f := range b.files {
+		b.size += f.bodySize() + len(f.note())
+	}
+}
+
+// update re-measures f after fn mutates it.
+func (b *budget) update(f *budgetFile, fn func()) {
+	before := f.bodySize() + len(f.note())
+	fn()
+	b.size += f.bodySize() + len(f.note()) - before
+}
+
+func (b *budget) largest(eligible func(*budgetFile) bool) *budgetFile {
+	var best *budgetFile
+	for _, f := range b.files {
+		if eligible(f) && (best == nil || f.bodySize() > best.bodySize()) {
+			best = f
+		}
+	}
+	return best
+}
+
+func (b *budget) dropLastHunk(f *budgetFile) {
+	b.update(f, func() {
+		last := f.Hunks[len(f.Hunks)-1]
+		f.Hunks = f.Hunks[:len(f.Hunks)-1]
+		f.omitted++
+		added, removed := countChanges(last)
+		f.added += added
+		f.removed += removed
+	})
+}
+
+func (b *budget) dropFile(f *budgetFile) {
+	b.update(f, func() {
+		for _, h := range f.Hunks {
+			added, removed := countChanges(h)
+			f.added += added
+			f.removed += removed
+		}
+		f.omitted += len(f.Hunks)
+		f.Hunks = nil
+		f.dropped = true
+	})
+}
+
+func (b *budget) render() string {
+	var out, notes strings.Builder
+	for _, f := range b.files {
+		if !f.dropped {
+			out.WriteString(f.String())
+		}
+		notes.WriteString(f.note())
+	}
+	out.WriteString(omissionNoteHeader)
+	out.WriteString(contextNote)
+	out.WriteString(notes.String())
+	return out.String()
+}
+
+// stripContext keeps a hunk's "@@" line and its added and removed lines.
+func stripContext(hunk string) string {
+	var out strings.Builder
+	for i, line := range strings.SplitAfter(hunk, "\n") {
+		if i == 0 || strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+			out.WriteString(line)
+		}
+	}
+	return out.String()
+}
+
+func countChanges(hunk string) (added, removed int) {
+	for i, line := range strings.SplitAfter(hunk, "\n") {
+		switch {
+		case i == 0:
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeStatus is git's one-letter status for a changed file.
+type ChangeStatus string
+
+const (
+	ChangeAdded       ChangeStatus = "A"
+	ChangeModified    ChangeStatus = "M"
+	ChangeDeleted     ChangeStatus = "D"
+	ChangeRenamed     ChangeStatus = "R"
+	ChangeCopied      ChangeStatus = "C"
+	ChangeTypeChanged ChangeStatus = "T"
+)
+
+// maxChangesListed caps the file table; past this the patch itself is
+// already budget-trimmed and the table would crowd it out.
+const maxChangesListed = 50
+
+// FileChange is one file's entry in a change set: what happened to it and
+// how many lines moved. OldPath is set for renames and copies; Binary files
+// have no line counts.
+type FileChange struct {
+	Status  ChangeStatus
+	Path    string
+	OldPath string
+	Added   int
+	Deleted int
+	Binary  bool
+}
+
+func (c FileChange) String() string {
+	path := c.Path
+	if c.OldPath != "" && c.OldPath != c.Path {
+		path = c.OldPath + " -> " + c.Path
+	}
+	if c.Binary {
+		return fmt.Sprintf("%s %s (binary)", c.Status, path)
+	}
+	return fmt.Sprintf("%s %s (+%d -%d)", c.Status, path, c.Added, c.Deleted)
+}
+
+// changeTable renders a compact file table: one line per file, status
+// first, so renames and deletions are explicit rather than inferred from
+// patch text.
+func changeTable(changes []FileChange) string {
+	if len(changes) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Changed files (A added, M modified, D deleted, " +
+		"R renamed, C copied, T type changed):\n")
+	for i, c := range changes {
+		if i == maxChangesListed {
+			fmt.Fprintf(&b, "... and %d more files\n", len(changes)-maxChangesListed)
+			break
+		}
+		b.WriteString(c.String())
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Keep a Changelog sections lazycommit writes, in the order they appear.
+// Breaking changes come first so they cannot be missed.
+const (
+	SectionBreaking = "Breaking Changes"
+	SectionAdded    = "Added"
+	SectionFixed    = "Fixed"
+	SectionChanged  = "Changed"
+)
+
+var changelogSections = []string{
+	SectionBreaking, SectionAdded, SectionFixed, SectionChanged,
+}
+
+// changelogTypes maps conventional types to sections. Types missing here
+// (docs, test, chore, ci, build, style) are not user-visible and get no
+// entry unless they are breaking.
+var changelogTypes = map[string]string{
+	"feat":     SectionAdded,
+	"fix":      SectionFixed,
+	"perf":     SectionChanged,
+	"refactor": SectionChanged,
+	"revert":   SectionChanged,
+}
+
+// ChangelogSection returns the section a commit message belongs in, or ""
+// when it does not warrant an entry. Messages that are not conventional
+// commits are assumed to be user-visible changes.
+func ChangelogSection(message string) string {
+	c, err := ParseConventionalCommit(message)
+	if err != nil {
+		return SectionChanged
+	}
+	if c.Breaking {
+		return SectionBreaking
+	}
+	return changelogTypes[strings.ToLower(c.Type)]
+}
+
+// ChangelogSections lists the sections in rendering order.
+func ChangelogSections() []string {
+	return append([]string(nil), changelogSections...)
+}
+
+// ParseChangelogEntries reads a bullet list from raw LLM output. Headings
+// and code fences are skipped, and a line that is not a bullet continues
+// the entry before it.
+func ParseChangelogEntries(raw string) []string {
+	var entries []string
+	for _, line := range strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") ||
+			strings.HasPrefix(trimmed, "```") {
+			continue
+		}
+		entry := stripListPrefix(trimmed)
+		if entry == trimmed && len(entries) > 0 {
+			entries[len(entries)-1] += " " + entry
+			continue
+		}
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// RenderChangelog writes a Keep a Changelog release: "## [Unreleased]"
+// when version is empty, else "## [version] - date". Sections come in
+// ChangelogSections order; empty ones are left out.
+func RenderChangelog(version, date string, entries map[string][]string) string {
+	var b strings.Builder
+	switch {
+	case version == "":
+		b.WriteString("## [Unreleased]\n")
+	case date == "":
+		fmt.Fprintf(&b, "## [%s]\n", version)
+	default:
+		fmt.Fprintf(&b, "## [%s] - %s\n", version, date)
+	}
+	for _, section := range changelogSections {
+		if len(entries[section]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", section)
+		for _, e := range entries[section] {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+	return b.String()
+}
+
+// changelogPreamble starts a CHANGELOG.md created by PrependRelease.
+const changelogPreamble = "# Changelog\n\n" +
+	"All notable changes to this project are documented in this file.\n"
+
+// PrependRelease inserts release above the newest release in a Keep a
+// Changelog file, after its title and introduction and below an
+// "## [Unreleased]" section. An empty file gets a preamble first.
+func PrependRelease(changelog, release string) string {
+	release = strings.TrimRight(release, "\n") + "\n"
+	if strings.TrimSpace(changelog) == "" {
+		return changelogPreamble + "\n" + release
+	}
+	at := nextReleaseHeading(changelog, 0)
+	if at >= 0 &&
+		strings.HasPrefix(strings.ToLower(changelog[at:]), "## [unreleased]") {
+		at = nextReleaseHeading(changelog, at+1)
+	}
+	if at < 0 {
+		return strings.TrimRight(changelog, "\n") + "\n\n" + release
+	}
+	return changelog[:at] + release + "\n" + changelog[at:]
+}
+
+// nextReleaseHeading returns the offset of the first line at or after
+// from that starts with "## ", or -1.
+func nextReleaseHeading(changelog string, from int) int {
+	for i := from; i < len(changelog); {
+		lineStart := i == 0 || changelog[i-1] == '\n'
+		if lineStart && strings.HasPrefix(changelog[i:], "## ") {
+			return i
+		}
+		j := strings.IndexByte(changelog[i:], '\n')
+		if j < 0 {
+			return -1
+		}
+		i += j + 1
+	}
+	return -1
+}
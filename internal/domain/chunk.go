@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DiffChunk is a self-contained slice of a larger diff, labelled with the
+// directory its files live in.
+type DiffChunk struct {
+	Label string
+	Diff  Diff
+}
+
+// ChunkDiff splits d into chunks of at most maxTokens each, grouping files
+// of the same directory together and keeping the diff's file order. A
+// single file larger than maxTokens becomes its own chunk, shrunk with
+// FitDiff. A non-positive maxTokens yields one chunk holding the whole diff.
+func ChunkDiff(d Diff, maxTokens int) []DiffChunk {
+	if maxTokens <= 0 {
+		return []DiffChunk{{Label: ".", Diff: d}}
+	}
+
+	var chunks []DiffChunk
+	var cur strings.Builder
+	var label string
+	flush := func() {
+		if cur.Len() > 0 {
+			chunks = append(chunks,
+				DiffChunk{Label: label, Diff: Diff{text: cur.String()}})
+			cur.Reset()
+		}
+	}
+
+	for _, f := range d.Files() {
+		dir := path.Dir(f.Path)
+		text := f.String()
+		if dir != label || estimateBytes(cur.Len()+len(text)) > maxTokens {
+			flush()
+			label = dir
+		}
+		if EstimateTokens(text) > maxTokens {
+			chunks = append(chunks, DiffChunk{
+				Label: dir,
+				Diff:  FitDiff(Diff{text: text}, maxTokens),
+			})
+			continue
+		}
+		cur.WriteString(text)
+	}
+	flush()
+	return chunks
+}
+
+// ChunkSummary is the generator's prose summary of one DiffChunk.
+type ChunkSummary struct {
+	Label string
+	Text  string
+}
+
+// NewChunkSummaryPrompt asks for a short prose summary of one chunk: the
+// map step of map-reduce generation.
+func NewChunkSummaryPrompt(system string, chunk DiffChunk) Prompt {
+	if strings.TrimSpace(system) == "" {
+		system = DefaultSystemMessage
+	}
+	user := fmt.Sprintf(ChunkSummaryTemplate, chunk.Label, chunk.Diff.String())
+	return Prompt{System: system, User: user}
+}
+
+// SummariesDiff renders chunk summaries, in order, as the stand-in for a
+// diff too large to send whole: the reduce step's input.
+func SummariesDiff(summaries []ChunkSummary) (Diff, error) {
+	var b strings.Builder
+	b.WriteString("The full diff is too large to include. " +
+		"These are summaries of each part, in diff order:\n")
+	written := false
+	for _, s := range summaries {
+		text := strings.TrimSpace(s.Text)
+		if text == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\n[%s]\n%s\n", s.Label, text)
+		written = true
+	}
+	if !written {
+		return Diff{}, errors.New("no chunk produced a summary")
+	}
+	return Diff{text: b.String()}, nil
+}
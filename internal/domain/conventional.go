@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// conventionalHeader is the Conventional Commits 1.0 header:
+	// type, optional (scope), optional "!", ": ", description.
+	conventionalHeader = regexp.MustCompile(`^([A-Za-z][A-Za-z-]*)(?:\(([^()\s][^()]*)\))?(!)?:[ \t]+(\S.*)$`)
+	// footerLine starts a footer: a hyphenated token (or the literal
+	// "BREAKING CHANGE") followed by ": " or " #".
+	footerLine = regexp.MustCompile(`^(BREAKING CHANGE|[A-Za-z][A-Za-z0-9-]*)(: | #)(.*)$`)
+)
+
+// Footer is a trailer such as "Refs: #123" or "BREAKING CHANGE: ...".
+// Separator is ": " or " #", as written.
+type Footer struct {
+	Token     string
+	Separator string
+	Value     string
+}
+
+func (f Footer) String() string {
+	return f.Token + f.Separator + f.Value
+}
+
+// IsBreaking reports whether the footer announces a breaking change.
+func (f Footer) IsBreaking() bool {
+	return f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE"
+}
+
+// ConventionalCommit is a commit message parsed per Conventional Commits
+// 1.0. Breaking is set by "!" in the header or a BREAKING CHANGE footer.
+type ConventionalCommit struct {
+	Type     string
+	Scope    string
+	Breaking bool
+	Subject  string
+	Body     string
+	Footers  []Footer
+
+	// bang records whether the header itself carried "!", so rendering
+	// round-trips messages that only declare breakage in a footer.
+	bang bool
+}
+
+// ParseConventionalCommit validates message against the Conventional
+// Commits grammar and splits it into its parts.
+func ParseConventionalCommit(message string) (ConventionalCommit, error) {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	header, rest, _ := strings.Cut(message, "\n")
+
+	m := conventionalHeader.FindStringSubmatch(strings.TrimSpace(header))
+	if m == nil {
+		return ConventionalCommit{}, fmt.Errorf(
+			"header %q is not \"type(scope): description\"", header)
+	}
+	c := ConventionalCommit{
+		Type:    m[1],
+		Scope:   strings.TrimSpace(m[2]),
+		bang:    m[3] == "!",
+		Subject: strings.TrimSpace(m[4]),
+	}
+	c.Breaking = c.bang
+
+	if rest != "" {
+		if !strings.HasPrefix(rest, "\n") {
+			return ConventionalCommit{}, errors.New(
+				"body must be separated from the header by a blank line")
+		}
+		c.Body, c.Footers = splitFooters(strings.Trim(rest, "\n"))
+	}
+	for _, f := range c.Footers {
+		if f.IsBreaking() {
+			c.Breaking = true
+		}
+	}
+	return c, nil
+}
+
+// splitFooters separates the trailing footer paragraph from the body. The
+// last paragraph counts as footers only when its first line is a footer;
+// continuation lines belong to the preceding footer.
+func splitFooters(text string) (string, []Footer) {
+	paragraphs := strings.Split(text, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+	lines := strings.Split(last, "\n")
+	if !footerLine.MatchString(lines[0]) {
+		return text, nil
+	}
+
+	var footers []Footer
+	for _, line := range lines {
+		if m := footerLine.FindStringSubmatch(line); m != nil {
+			footers = append(footers, Footer{Token: m[1], Separator: m[2], Value: m[3]})
+			continue
+		}
+		f := &footers[len(footers)-1]
+		f.Value += "\n" + line
+	}
+	body := strings.TrimSpace(strings.Join(paragraphs[:len(paragraphs)-1], "\n\n"))
+	return body, footers
+}
+
+// Normalize applies the mechanical conventions: lower-case type,
+// single-spaced subject without a trailing full stop, and a lower-case
+// first letter unless the first word is an acronym.
+func (c ConventionalCommit) Normalize() ConventionalCommit {
+	c.Type = strings.ToLower(c.Type)
+	c.Subject = strings.Join(strings.Fields(c.Subject), " ")
+	c.Subject = strings.TrimRight(c.Subject, ".")
+	c.Subject = lowerFirst(c.Subject)
+	return c
+}
+
+// Header renders "type(scope)!: subject".
+func (c ConventionalCommit) Header() string {
+	var b strings.Builder
+	b.WriteString(c.Type)
+	if c.Scope != "" {
+		b.WriteString("(" + c.Scope + ")")
+	}
+	if c.bang || (c.Breaking && !c.hasBreakingFooter()) {
+		b.WriteString("!")
+	}
+	b.WriteString(": " + c.Subject)
+	return b.String()
+}
+
+// String renders the full message: header, then body and footers each
+// after a blank line.
+func (c ConventionalCommit) String() string {
+	parts := []string{c.Header()}
+	if c.Body != "" {
+		parts = append(parts, c.Body)
+	}
+	if len(c.Footers) > 0 {
+		lines := make([]string, len(c.Footers))
+		for i, f := range c.Footers {
+			lines[i] = f.String()
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func (c ConventionalCommit) hasBreakingFooter() bool {
+	for _, f := range c.Footers {
+		if f.IsBreaking() {
+			return true
+		}
+	}
+	return false
+}
+
+func lowerFirst(s string) string {
+	first, size := utf8.DecodeRuneInString(s)
+	if first == utf8.RuneError || !unicode.IsUpper(first) {
+		return s
+	}
+	if next, _ := utf8.DecodeRuneInString(s[size:]); unicode.IsUpper(next) {
+		return s
+	}
+	return string(unicode.ToLower(first)) + s[size:]
+}
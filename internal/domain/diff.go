@@ -24,3 +24,74 @@ func NewDiff(text string) (Diff, error) {
 func (d Diff) String() string {
 	return d.text
 }
+
+// FileDiff is one file's section of a unified diff: the header lines up to
+// the first hunk, then one entry per hunk starting at its "@@" line. Every
+// line keeps its trailing newline, so concatenating Header and Hunks
+// reproduces the section exactly.
+type FileDiff struct {
+	Path   string
+	Header string
+	Hunks  []string
+}
+
+func (f FileDiff) String() string {
+	return f.Header + strings.Join(f.Hunks, "")
+}
+
+// Files splits the diff into per-file sections in their original order.
+// Text before the first "diff --git" line, if any, becomes a section with an
+// empty Path.
+func (d Diff) Files() []FileDiff {
+	var files []FileDiff
+	var cur *FileDiff
+	for _, line := range strings.SplitAfter(d.text, "\n") {
+		if line == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, FileDiff{Header: line})
+			cur = &files[len(files)-1]
+		case cur == nil:
+			files = append(files, FileDiff{Header: line})
+			cur = &files[len(files)-1]
+		case strings.HasPrefix(line, "@@"):
+			cur.Hunks = append(cur.Hunks, line)
+		case len(cur.Hunks) > 0:
+			cur.Hunks[len(cur.Hunks)-1] += line
+		default:
+			cur.Header += line
+		}
+	}
+	for i := range files {
+		files[i].Path = headerPath(files[i].Header)
+	}
+	return files
+}
+
+// headerPath extracts the file path from a file section header, preferring
+// the post-image name so renames and additions report where the file now
+// lives.
+func headerPath(header string) string {
+	var oldPath, gitLine string
+	for _, line := range strings.Split(header, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ b/"):
+			return strings.TrimPrefix(line, "+++ b/")
+		case strings.HasPrefix(line, "rename to "):
+			return strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "--- a/"):
+			oldPath = strings.TrimPrefix(line, "--- a/")
+		case strings.HasPrefix(line, "diff --git "):
+			gitLine = strings.TrimPrefix(line, "diff --git ")
+		}
+	}
+	if oldPath != "" {
+		return oldPath
+	}
+	if i := strings.LastIndex(gitLine, " b/"); i >= 0 {
+		return gitLine[i+len(" b/"):]
+	}
+	return ""
+}
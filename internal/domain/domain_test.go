@@ -2,6 +2,8 @@ package domain
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -182,3 +184,663 @@ func TestParseSuggestionsZeroMax(t *testing.T) {
 		t.Fatalf("expected nil for max<=0, got %v", got)
 	}
 }
+
+const twoFileDiff = "diff --git a/small.go b/small.go\n" +
+	"--- a/small.go\n" +
+	"+++ b/small.go\n" +
+	"@@ -1,3 +1,3 @@\n" +
+	" package small\n" +
+	"-var x = 1\n" +
+	"+var x = 2\n" +
+	"diff --git a/big.go b/big.go\n" +
+	"--- a/big.go\n" +
+	"+++ b/big.go\n" +
+	"@@ -1,2 +1,3 @@\n" +
+	" package big\n" +
+	"+var a = 1\n" +
+	"@@ -10,2 +11,3 @@\n" +
+	" func f() {}\n" +
+	"+func g() {}\n" +
+	"+func h() {}\n"
+
+func TestDiffFiles(t *testing.T) {
+	diff, _ := NewDiff(twoFileDiff)
+	files := diff.Files()
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+	if files[0].Path != "small.go" || files[1].Path != "big.go" {
+		t.Fatalf("paths = %q, %q", files[0].Path, files[1].Path)
+	}
+	if len(files[0].Hunks) != 1 || len(files[1].Hunks) != 2 {
+		t.Fatalf("hunk counts = %d, %d", len(files[0].Hunks), len(files[1].Hunks))
+	}
+	if files[0].String()+files[1].String() != twoFileDiff {
+		t.Fatal("concatenated sections must reproduce the diff")
+	}
+}
+
+func TestDiffFilesDeletedAndRenamed(t *testing.T) {
+	diff, _ := NewDiff("diff --git a/gone.go b/gone.go\n" +
+		"deleted file mode 100644\n" +
+		"--- a/gone.go\n" +
+		"+++ /dev/null\n" +
+		"@@ -1 +0,0 @@\n" +
+		"-package gone\n" +
+		"diff --git a/old.go b/new.go\n" +
+		"similarity index 100%\n" +
+		"rename from old.go\n" +
+		"rename to new.go\n")
+	files := diff.Files()
+	if len(files) != 2 || files[0].Path != "gone.go" || files[1].Path != "new.go" {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+}
+
+func TestFitDiffUnchangedWhenWithinBudget(t *testing.T) {
+	diff, _ := NewDiff(twoFileDiff)
+	if got := FitDiff(diff, 0); got.String() != twoFileDiff {
+		t.Fatal("zero budget must mean unlimited")
+	}
+	if got := FitDiff(diff, EstimateTokens(twoFileDiff)); got.String() != twoFileDiff {
+		t.Fatal("diff within budget must be returned unchanged")
+	}
+}
+
+// budgetFixture is a diff large enough that the omission note is small
+// relative to it: one small file, one large file with two hunks, and plenty
+// of unchanged context.
+func budgetFixture() string {
+	context := strings.Repeat(" unchanged context line\n", 20)
+	return "diff --git a/small.go b/small.go\n--- a/small.go\n+++ b/small.go\n" +
+		"@@ -1,21 +1,21 @@\n" + context + "-var x = 1\n+var x = 2\n" +
+		"diff --git a/big.go b/big.go\n--- a/big.go\n+++ b/big.go\n" +
+		"@@ -1,20 +1,30 @@\n" + context + strings.Repeat("+first hunk addition\n", 10) +
+		"@@ -50,20 +60,30 @@\n" + context + strings.Repeat("+second hunk addition\n", 10)
+}
+
+func TestFitDiffDropsContextFirst(t *testing.T) {
+	fixture := budgetFixture()
+	diff, _ := NewDiff(fixture)
+	got := FitDiff(diff, EstimateTokens(fixture)-1).String()
+
+	if strings.Contains(got, " unchanged context line\n") {
+		t.Fatalf("context lines should be dropped:\n%s", got)
+	}
+	for _, want := range []string{"+var x = 2", "+first hunk addition", "+second hunk addition", "context lines were removed"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "hunks omitted") {
+		t.Fatalf("no hunk should be dropped when stripping context suffices:\n%s", got)
+	}
+}
+
+func TestFitDiffDropsHunksOfLargestFileWithSummary(t *testing.T) {
+	diff, _ := NewDiff(budgetFixture())
+	budget := 150
+	got := FitDiff(diff, budget).String()
+
+	if EstimateTokens(got) > budget {
+		t.Fatalf("result exceeds budget: %d tokens", EstimateTokens(got))
+	}
+	if !strings.Contains(got, "+var x = 2") || !strings.Contains(got, "+first hunk addition") {
+		t.Fatalf("small file and first hunk should survive:\n%s", got)
+	}
+	if strings.Contains(got, "+second hunk addition") {
+		t.Fatalf("last hunk of the largest file should be dropped:\n%s", got)
+	}
+	if !strings.Contains(got, "- big.go: 1 of 2 hunks omitted (+10 -0 lines)") {
+		t.Fatalf("missing per-file summary:\n%s", got)
+	}
+}
+
+func TestFitDiffAlwaysFits(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 50; i++ {
+		b.WriteString("diff --git a/f b/f\n--- a/f\n+++ b/f\n@@ -1 +1 @@\n-" + strings.Repeat("x", 80) + "\n+y\n")
+	}
+	diff, _ := NewDiff(b.String())
+	for _, budget := range []int{1, 10, 50, 200} {
+		if got := FitDiff(diff, budget).String(); EstimateTokens(got) > budget {
+			t.Fatalf("budget %d exceeded: %d tokens", budget, EstimateTokens(got))
+		}
+	}
+}
+
+func TestChunkDiffGroupsByDirectoryInOrder(t *testing.T) {
+	file := func(p, body string) string {
+		return "diff --git a/" + p + " b/" + p + "\n--- a/" + p + "\n+++ b/" + p + "\n@@ -1 +1 @@\n+" + body + "\n"
+	}
+	diff, _ := NewDiff(file("api/a.go", "a") + file("api/b.go", "b") + file("web/c.ts", "c") +
+		file("web/huge.ts", strings.Repeat("z", 2000)))
+
+	chunks := ChunkDiff(diff, 100)
+	var labels []string
+	for _, c := range chunks {
+		labels = append(labels, c.Label)
+		if EstimateTokens(c.Diff.String()) > 100 {
+			t.Fatalf("chunk %s exceeds budget", c.Label)
+		}
+	}
+	if strings.Join(labels, ",") != "api,web,web" {
+		t.Fatalf("labels = %v", labels)
+	}
+	if !strings.Contains(chunks[0].Diff.String(), "+a") || !strings.Contains(chunks[0].Diff.String(), "+b") {
+		t.Fatalf("files of the same directory should share a chunk: %q", chunks[0].Diff.String())
+	}
+}
+
+func TestSummariesDiff(t *testing.T) {
+	d, err := SummariesDiff([]ChunkSummary{{Label: "api", Text: " adds login "}, {Label: "web", Text: ""}, {Label: "docs", Text: "typos"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := d.String()
+	if !strings.Contains(got, "[api]\nadds login") || strings.Contains(got, "[web]") ||
+		strings.Index(got, "[api]") > strings.Index(got, "[docs]") {
+		t.Fatalf("unexpected combined summaries: %q", got)
+	}
+	if _, err := SummariesDiff([]ChunkSummary{{Label: "x", Text: " "}}); err == nil {
+		t.Fatal("expected error when no summary has text")
+	}
+}
+
+func TestPromptBuilderExcludedPathsNote(t *testing.T) {
+	diff, _ := NewDiff("+x")
+	if p := NewPromptBuilder().Build(diff); strings.Contains(p.User, "Also updated") {
+		t.Fatal("no note expected without excluded paths")
+	}
+
+	var paths []string
+	for i := 0; i < 25; i++ {
+		paths = append(paths, fmt.Sprintf("lock%d", i))
+	}
+	p := NewPromptBuilder().WithExcludedPaths(paths).Build(diff)
+	if !strings.Contains(p.User, "Also updated (not shown in the diff): lock0, lock1,") {
+		t.Fatalf("missing note: %q", p.User)
+	}
+	if strings.Contains(p.User, "lock20") || !strings.Contains(p.User, "lock19 and 5 more.") {
+		t.Fatalf("note should be capped: %q", p.User)
+	}
+}
+
+func TestPromptBuilderRendersChangeTableAheadOfDiff(t *testing.T) {
+	diff, _ := NewDiff("+x")
+	p := NewPromptBuilder().WithChanges([]FileChange{
+		{Status: ChangeRenamed, OldPath: "old.go", Path: "new.go"},
+		{Status: ChangeDeleted, Path: "legacy.go", Deleted: 120},
+		{Status: ChangeAdded, Path: "logo.png", Binary: true},
+	}).Build(diff)
+
+	for _, want := range []string{"R old.go -> new.go (+0 -0)", "D legacy.go (+0 -120)", "A logo.png (binary)"} {
+		if !strings.Contains(p.User, want) {
+			t.Fatalf("missing %q in %q", want, p.User)
+		}
+	}
+	if strings.Index(p.User, "legacy.go") > strings.Index(p.User, "+x") {
+		t.Fatal("file table must come before the diff")
+	}
+}
+
+func TestExtractTicket(t *testing.T) {
+	group := regexp.MustCompile(`^(?:feature|fix)/([A-Z]+-\d+)`)
+	if tk, ok := ExtractTicket(group, "feature/PAY-1234-refund-flow"); !ok || tk.String() != "PAY-1234" {
+		t.Fatalf("capture group not used: %q %v", tk, ok)
+	}
+	whole := regexp.MustCompile(`[A-Z]+-\d+`)
+	if tk, ok := ExtractTicket(whole, "PAY-9-x"); !ok || tk.String() != "PAY-9" {
+		t.Fatalf("whole match not used: %q %v", tk, ok)
+	}
+	if _, ok := ExtractTicket(whole, "main"); ok {
+		t.Fatal("no ticket expected on main")
+	}
+	if _, ok := ExtractTicket(nil, "PAY-1"); ok {
+		t.Fatal("nil pattern must disable extraction")
+	}
+}
+
+func TestTicketPrefixIsIdempotent(t *testing.T) {
+	tk, _ := ExtractTicket(regexp.MustCompile(`[A-Z]+-\d+`), "PAY-7")
+	for _, in := range []string{"feat: x", "PAY-7: feat: x", "pay-7 feat: x", "[PAY-7] feat: x", "(PAY-7) - feat: x"} {
+		s, _ := NewSuggestion(in)
+		if got := tk.Prefix(s, "%s: ").String(); got != "PAY-7: feat: x" {
+			t.Fatalf("Prefix(%q) = %q", in, got)
+		}
+	}
+	s, _ := NewSuggestion("feat: x")
+	if got := tk.Prefix(s, "[%s] ").String(); got != "[PAY-7] feat: x" {
+		t.Fatalf("custom format ignored: %q", got)
+	}
+}
+
+func TestScopeMapInfer(t *testing.T) {
+	m, err := NewScopeMap(map[string]string{
+		"services/billing/**": "billing",
+		"web/**/*.tsx":        "web",
+		"*.md":                "docs",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(m.Allowed(), ","); got != "billing,docs,web" {
+		t.Fatalf("allowed = %q", got)
+	}
+	got := m.Infer([]string{"services/billing/api/refund.go", "web/app/page.tsx", "web/app/page.css", "docs/guide.md"})
+	if strings.Join(got, ",") != "billing,web" {
+		t.Fatalf("inferred = %v (\"*.md\" must not cross directories)", got)
+	}
+	if got := m.Infer([]string{"web/page.tsx"}); strings.Join(got, ",") != "web" {
+		t.Fatalf("\"**/\" should match zero directories, got %v", got)
+	}
+}
+
+func TestScopeMapEnforce(t *testing.T) {
+	m, _ := NewScopeMap(map[string]string{"billing/**": "billing", "web/**": "web"})
+	cases := []struct {
+		in       string
+		inferred []string
+		want     string
+		ok       bool
+	}{
+		{"feat(billing): add refunds", []string{"billing"}, "feat(billing): add refunds", true},
+		{"feat(payments): add refunds", []string{"billing"}, "feat(billing): add refunds", true},
+		{"feat(payments)!: drop v1", []string{"billing"}, "feat(billing)!: drop v1", true},
+		{"fix: rounding", []string{"billing"}, "fix(billing): rounding", true},
+		{"feat(payments): add refunds", []string{"billing", "web"}, "", false},
+		{"fix: rounding", []string{"billing", "web"}, "fix: rounding", true},
+		{"Update readme", []string{"billing"}, "Update readme", true},
+	}
+	for _, tc := range cases {
+		s, _ := NewSuggestion(tc.in)
+		got, ok := m.Enforce(s, tc.inferred)
+		if ok != tc.ok || (ok && got.String() != tc.want) {
+			t.Fatalf("Enforce(%q, %v) = %q, %v; want %q, %v", tc.in, tc.inferred, got.String(), ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestNewScopeMapRejectsEmptyScope(t *testing.T) {
+	if _, err := NewScopeMap(map[string]string{"x/**": " "}); err == nil {
+		t.Fatal("expected error for empty scope")
+	}
+}
+
+func TestParseConventionalCommit(t *testing.T) {
+	c, err := ParseConventionalCommit("feat(api)!: drop v1 endpoints\n\n" +
+		"The v1 API has been deprecated for a year.\n\nClients must migrate.\n\n" +
+		"Refs: #42\nBREAKING CHANGE: /v1 now returns 410\n  for every route\nReviewed-by: Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Type != "feat" || c.Scope != "api" || !c.Breaking || c.Subject != "drop v1 endpoints" {
+		t.Fatalf("unexpected header fields: %+v", c)
+	}
+	if c.Body != "The v1 API has been deprecated for a year.\n\nClients must migrate." {
+		t.Fatalf("body = %q", c.Body)
+	}
+	if len(c.Footers) != 3 || c.Footers[0].String() != "Refs: #42" ||
+		c.Footers[1].Value != "/v1 now returns 410\n  for every route" || !c.Footers[1].IsBreaking() {
+		t.Fatalf("footers = %+v", c.Footers)
+	}
+
+	c, err = ParseConventionalCommit("fix: handle nil\n\nthis fixes the crash: see #12")
+	if err != nil || c.Body != "this fixes the crash: see #12" || len(c.Footers) != 0 {
+		t.Fatalf("lower-case prose is body, not a footer: %+v %v", c, err)
+	}
+
+	c, err = ParseConventionalCommit("refactor: rename\n\nBREAKING-CHANGE: config keys renamed")
+	if err != nil || !c.Breaking || c.Header() != "refactor: rename" {
+		t.Fatalf("footer-only breaking change: %+v %v", c, err)
+	}
+}
+
+func TestParseConventionalCommitRejectsTicketPrefix(t *testing.T) {
+	for _, msg := range []string{"PAY-12: feat: x", "[PAY-12] feat: x", "#12 feat: x"} {
+		if _, err := ParseConventionalCommit(msg); err == nil {
+			t.Fatalf("%q accepted; the ticket must be stripped first", msg)
+		}
+	}
+}
+
+func TestTicketPrefixStrip(t *testing.T) {
+	for format, cases := range map[string]map[string]string{
+		"": {
+			"PAY-1234: feat(api)!: drop v1": "feat(api)!: drop v1",
+			"feat: x":                       "feat: x",
+		},
+		"[%s] ": {
+			"[PAY-1234] feat: x": "feat: x",
+			"PAY-1234: feat: x":  "PAY-1234: feat: x",
+		},
+		"%s - ": {
+			"PAY-1234 - feat: x": "feat: x",
+			"#12 - fix: y":       "fix: y",
+			"Rework - feat: x":   "Rework - feat: x",
+		},
+	} {
+		p := NewTicketPrefix(format)
+		for in, want := range cases {
+			if got := p.Strip(in); got != want {
+				t.Fatalf("format %q: Strip(%q) = %q, want %q", format, in, got, want)
+			}
+		}
+	}
+	if got := (TicketPrefix{}).Strip("PAY-1: feat: x"); got != "PAY-1: feat: x" {
+		t.Fatalf("zero TicketPrefix stripped %q", got)
+	}
+}
+
+func TestParseConventionalCommitRejectsMalformed(t *testing.T) {
+	for _, msg := range []string{
+		"Update readme",
+		"feat:missing space",
+		"feat(): empty scope",
+		"feat(api) : space before colon",
+		"1feat: digit type",
+		"feat: ok\nbody without blank line",
+		"feat: ",
+	} {
+		if _, err := ParseConventionalCommit(msg); err == nil {
+			t.Fatalf("expected %q to be rejected", msg)
+		}
+	}
+}
+
+func TestConventionalCommitNormalizeAndRender(t *testing.T) {
+	c, _ := ParseConventionalCommit("FEAT(api):   Add  login flow.")
+	if got := c.Normalize().String(); got != "feat(api): add login flow" {
+		t.Fatalf("normalized = %q", got)
+	}
+	c, _ = ParseConventionalCommit("fix: API timeouts")
+	if got := c.Normalize().Subject; got != "API timeouts" {
+		t.Fatalf("acronyms must keep their case, got %q", got)
+	}
+	msg := "feat!: x\n\nbody\n\nRefs: #1"
+	c, _ = ParseConventionalCommit(msg)
+	if c.String() != msg {
+		t.Fatalf("round trip = %q", c.String())
+	}
+}
+
+func TestNormalizeConventionalDropsMalformed(t *testing.T) {
+	var in []Suggestion
+	for _, text := range []string{"Suggestions", "feat: Add login.", "fix(ui): align button"} {
+		s, _ := NewSuggestion(text)
+		in = append(in, s)
+	}
+	got := NormalizeConventional(in)
+	if len(got) != 2 || got[0].String() != "feat: add login" || got[1].String() != "fix(ui): align button" {
+		t.Fatalf("unexpected: %v", got)
+	}
+	c, err := got[1].Conventional()
+	if err != nil || c.Type != "fix" || c.Scope != "ui" {
+		t.Fatalf("fields not exposed: %+v %v", c, err)
+	}
+}
+
+func TestParseMessagesSplitsOnSeparatorLines(t *testing.T) {
+	raw := "```\n1. feat: add login\nbody right after the subject\n\n" + MessageSeparator + "\n\nfix: guard nil\n\n" +
+		"- first point\n- second point\n\nBREAKING CHANGE: sessions expire\n" + MessageSeparator + "\n\n```"
+	got := ParseMessages(raw, 10)
+	if len(got) != 2 {
+		t.Fatalf("got %d messages: %q", len(got), got)
+	}
+	if got[0].String() != "feat: add login\n\nbody right after the subject" {
+		t.Fatalf("first = %q", got[0].String())
+	}
+	want := "fix: guard nil\n\n- first point\n- second point\n\nBREAKING CHANGE: sessions expire"
+	if got[1].String() != want {
+		t.Fatalf("second = %q, want %q", got[1].String(), want)
+	}
+	if len(ParseMessages(raw, 1)) != 1 {
+		t.Fatal("max not applied")
+	}
+}
+
+func TestParseMessagesKeepsMarkdownRulesAndCode(t *testing.T) {
+	raw := "feat: add login\n\nBefore.\n\n---\n\n```go\nfunc login() {\n\n\treturn\n}\n```\n" +
+		MessageSeparator + "\nfix: guard nil"
+	got := ParseMessages(raw, 10)
+	if len(got) != 2 {
+		t.Fatalf("got %d messages: %q", len(got), got)
+	}
+	want := "feat: add login\n\nBefore.\n\n---\n\n```go\nfunc login() {\n\n\treturn\n}\n```"
+	if got[0].String() != want {
+		t.Fatalf("first = %q, want %q", got[0].String(), want)
+	}
+}
+
+func TestWrapBody(t *testing.T) {
+	long := strings.Repeat("word ", 20)
+	got := WrapBody(long+"\n\n- "+long, 30)
+	for _, line := range strings.Split(got, "\n") {
+		if len(line) > 30 {
+			t.Fatalf("line %q exceeds width", line)
+		}
+	}
+	if !strings.Contains(got, "\n\n- word") || !strings.Contains(got, "\n  word") {
+		t.Fatalf("paragraphs or hanging indent lost: %q", got)
+	}
+}
+
+func TestWrapBodyKeepsListsCodeAndTables(t *testing.T) {
+	body := "Some prose\nthat flows on.\n\n" +
+		"- short item\n  continued here\n- another\n\n" +
+		"    indented code that is longer than the width\n\n" +
+		"```\nfenced code that is longer than the width\n```\n\n" +
+		"| a | table row longer than the width |"
+	want := "Some prose that flows on.\n\n" +
+		"- short item\n  continued here\n- another\n\n" +
+		"    indented code that is longer than the width\n\n" +
+		"```\nfenced code that is longer than the width\n```\n\n" +
+		"| a | table row longer than the width |"
+	if got := WrapBody(body, 30); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNewMessageValidatesSubject(t *testing.T) {
+	if _, err := NewMessage(strings.Repeat("x", MaxSuggestionLength+1) + "\n\nbody"); err == nil {
+		t.Fatal("expected over-long subject to be rejected")
+	}
+	if _, err := NewMessage("subject\nbody"); err == nil {
+		t.Fatal("expected missing blank line to be rejected")
+	}
+	s, err := NewMessage("subject\n\nbody")
+	if err != nil || s.Subject() != "subject" {
+		t.Fatalf("got %q, %v", s.Subject(), err)
+	}
+}
+
+func TestBranchSlugAndFormat(t *testing.T) {
+	ticket, _ := NewTicket("PAY-12")
+	cases := []struct{ in, want string }{
+		{"Add Login Page", "add-login-page"},
+		{"feat/add_login--page!", "add-login-page"},
+		{"pay-12-add-login", "add-login"},
+		{"***", ""},
+		{strings.Repeat("word ", 20), strings.TrimSuffix(strings.Repeat("word-", 10), "-")},
+	}
+	for _, c := range cases {
+		if got := BranchSlug(c.in, ticket); got != c.want {
+			t.Errorf("BranchSlug(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+	if got := FormatBranchName("feat/", ticket, "add-login"); got != "feat/pay-12-add-login" {
+		t.Fatalf("got %q", got)
+	}
+	if got := FormatBranchName("", Ticket{}, "add-login"); got != "add-login" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestChangelogSectionsAndRendering(t *testing.T) {
+	for msg, want := range map[string]string{
+		"feat(api): add search":                        SectionAdded,
+		"fix: guard nil":                               SectionFixed,
+		"refactor!: drop v1 routes":                    SectionBreaking,
+		"chore: bump deps\n\nBREAKING CHANGE: go 1.25": SectionBreaking,
+		"docs: fix typo":                               "",
+		"Update the readme":                            SectionChanged,
+	} {
+		if got := ChangelogSection(msg); got != want {
+			t.Errorf("ChangelogSection(%q) = %q, want %q", msg, got, want)
+		}
+	}
+
+	entries := ParseChangelogEntries("```\n### Added\n- Search across\n  all projects.\n* Export to CSV\n```")
+	if strings.Join(entries, "|") != "Search across all projects.|Export to CSV" {
+		t.Fatalf("entries = %q", entries)
+	}
+	release := RenderChangelog("1.2.0", "2026-10-17", map[string][]string{
+		SectionFixed: {"Crash on empty input."},
+		SectionAdded: entries,
+	})
+	want := "## [1.2.0] - 2026-10-17\n\n### Added\n\n- Search across all projects.\n- Export to CSV\n\n### Fixed\n\n- Crash on empty input.\n"
+	if release != want {
+		t.Fatalf("release = %q", release)
+	}
+
+	existing := "# Changelog\n\nIntro.\n\n## [1.1.0] - 2026-09-01\n\n- Old.\n"
+	if got := PrependRelease(existing, release); got != "# Changelog\n\nIntro.\n\n"+want+"\n## [1.1.0] - 2026-09-01\n\n- Old.\n" {
+		t.Fatalf("prepended = %q", got)
+	}
+	if got := PrependRelease("", release); !strings.HasPrefix(got, "# Changelog\n") || !strings.HasSuffix(got, want) {
+		t.Fatalf("new file = %q", got)
+	}
+
+	unreleased := "# Changelog\n\n## [Unreleased]\n\n- Pending.\n"
+	old := "## [1.1.0] - 2026-09-01\n\n- Old.\n"
+	got := PrependRelease(unreleased+"\n"+old, release)
+	if got != unreleased+"\n"+want+"\n"+old {
+		t.Fatalf("below Unreleased = %q", got)
+	}
+	if got := PrependRelease(unreleased, release); got != unreleased+"\n"+want {
+		t.Fatalf("after a lone Unreleased = %q", got)
+	}
+}
+
+func TestSemverBump(t *testing.T) {
+	latest, ok := LatestVersion([]string{"v1.9.0", "v1.10.0", "v2.0.0-rc.1", "nightly", "v1.2.3"})
+	if !ok || latest.String() != "v1.10.0" {
+		t.Fatalf("latest = %v, %v", latest, ok)
+	}
+	for impact, want := range map[ReleaseImpact]string{
+		ImpactNone: "v1.10.0", ImpactPatch: "v1.10.1", ImpactMinor: "v1.11.0", ImpactMajor: "v2.0.0",
+	} {
+		if got := latest.Bump(impact).String(); got != want {
+			t.Errorf("Bump(%s) = %s, want %s", impact, got, want)
+		}
+	}
+	for msg, want := range map[string]ReleaseImpact{
+		"feat: add x": ImpactMinor, "fix(api): y": ImpactPatch, "chore!: drop go 1.21": ImpactMajor, "docs: z": ImpactNone,
+	} {
+		if got, ok := ConventionalImpact(msg); !ok || got != want {
+			t.Errorf("ConventionalImpact(%q) = %s, %v", msg, got, ok)
+		}
+	}
+	if _, ok := ConventionalImpact("wip"); ok {
+		t.Fatal("wip is not conventional")
+	}
+	impact, reason, err := ParseImpactAnswer("**Minor**: adds a flag\n")
+	if err != nil || impact != ImpactMinor || reason != "adds a flag" {
+		t.Fatalf("got %s %q %v", impact, reason, err)
+	}
+	impact, reason, err = ParseImpactAnswer("Here is the classification:\n\nmajor: drops the v1 API")
+	if err != nil || impact != ImpactMajor || reason != "drops the v1 API" {
+		t.Fatalf("preamble not skipped: %v %q %v", impact, reason, err)
+	}
+	if _, _, err := ParseImpactAnswer("probably minor"); err == nil {
+		t.Fatal("expected an unexpected answer to fail")
+	}
+}
+
+func TestParseDocument(t *testing.T) {
+	s, ok := ParseDocument("```markdown\n## Summary\n\nAdds login.\n\n---\n\n## Testing\n- unit tests\n```\n")
+	if !ok || s.String() != "## Summary\n\nAdds login.\n\n---\n\n## Testing\n- unit tests" {
+		t.Fatalf("got %q, %v", s.String(), ok)
+	}
+	if _, ok := ParseDocument(" \n```\n```"); ok {
+		t.Fatal("expected empty output to be rejected")
+	}
+}
+
+func conventionalLintRules(t *testing.T) LintRules {
+	t.Helper()
+	rules, err := NewLintRules([]RuleSpec{
+		{Name: RuleHeaderMaxLength, Level: RuleError, Value: 30},
+		{Name: RuleTypeEnum, Level: RuleError, Value: []string{"feat", "fix"}},
+		{Name: RuleSubjectCase, Level: RuleError, Never: true, Value: []string{"sentence-case", "upper-case"}},
+		{Name: RuleSubjectFullStop, Level: RuleError, Never: true, Value: "."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rules
+}
+
+func TestLintRulesFixMechanicalViolations(t *testing.T) {
+	rules := conventionalLintRules(t)
+	for in, want := range map[string]string{
+		"Feat: Add login.":                         "feat: add login",
+		"fix: API timeouts":                        "fix: api timeouts",
+		"feat(ui): add a rather long subject line": "feat(ui): add a rather long",
+		"fix: ok\n\nBody stays.":                   "fix: ok\n\nBody stays.",
+	} {
+		s, _ := NewMessage(in)
+		got, violations := rules.Apply(s)
+		if len(violations) != 0 || got.String() != want {
+			t.Fatalf("Apply(%q) = %q, %v; want %q", in, got.String(), violations, want)
+		}
+	}
+}
+
+func TestLintRulesReportUnfixable(t *testing.T) {
+	rules := conventionalLintRules(t)
+	for _, in := range []string{"build: bump deps", "update the readme", "feat: supercalifragilisticexpialidocious"} {
+		s, _ := NewSuggestion(in)
+		if _, violations := rules.Apply(s); len(violations) != 1 || violations[0].Level != RuleError {
+			t.Fatalf("Apply(%q) violations = %v", in, violations)
+		}
+	}
+}
+
+func TestNewLintRulesValidates(t *testing.T) {
+	for _, spec := range []RuleSpec{
+		{Name: "body-leading-blank", Level: RuleError},
+		{Name: RuleHeaderMaxLength, Level: RuleError, Value: "72"},
+		{Name: RuleSubjectCase, Level: RuleError, Value: []string{"shouting-case"}},
+		{Name: RuleTypeEnum, Level: 3, Value: []string{"feat"}},
+	} {
+		if _, err := NewLintRules([]RuleSpec{spec}); err == nil {
+			t.Fatalf("expected %+v to be rejected", spec)
+		}
+	}
+	rules, err := NewLintRules([]RuleSpec{{Name: RuleTypeEnum, Level: RuleDisabled}})
+	if err != nil || !rules.Empty() {
+		t.Fatalf("disabled rule should be dropped: %v", err)
+	}
+}
+
+func TestDraftCommitMessage(t *testing.T) {
+	var suggestions []Suggestion
+	for _, text := range []string{"feat: add login", "fix: guard nil\n\nWhy."} {
+		s, _ := NewMessage(text)
+		suggestions = append(suggestions, s)
+	}
+	template := "\n# Please enter the commit message for your changes.\n"
+	got := DraftCommitMessage(suggestions, template, "")
+	want := "feat: add login\n\n# Other suggestions from lazycommit:\n#\n#   fix: guard nil\n#\n#   Why.\n" + template
+	if got != want {
+		t.Fatalf("draft = %q, want %q", got, want)
+	}
+
+	template = "\n; Please enter the commit message for your changes.\n"
+	got = DraftCommitMessage(suggestions, template, ";")
+	want = "feat: add login\n\n; Other suggestions from lazycommit:\n;\n;   fix: guard nil\n;\n;   Why.\n" + template
+	if got != want {
+		t.Fatalf("draft with core.commentChar ';' = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "strings"
+
+const (
+	// PrepareCommitMsgHook is the git hook lazycommit installs.
+	PrepareCommitMsgHook = "prepare-commit-msg"
+
+	// HookMarker identifies hook scripts written by lazycommit.
+	HookMarker = "# Installed by lazycommit."
+
+	// ChainedHookSuffix is appended to the name of a hook that lazycommit
+	// replaced; the installed script runs it first.
+	ChainedHookSuffix = ".lazycommit-chained"
+)
+
+// PrepareCommitMsgScript is the installed hook: it runs any chained hook,
+// then fills the message file. A missing binary or a failed generation
+// never blocks the commit.
+const PrepareCommitMsgScript = `#!/bin/sh
+` + HookMarker + ` Remove with: lazycommit hook uninstall
+chained="$0` + ChainedHookSuffix + `"
+if [ -x "$chained" ]; then
+	"$chained" "$@" || exit $?
+fi
+command -v lazycommit >/dev/null 2>&1 || exit 0
+lazycommit hook run "$@" || true
+`
+
+// DefaultCommentChar starts comment lines in a commit message unless
+// core.commentChar says otherwise.
+const DefaultCommentChar = "#"
+
+// DraftCommitMessage fills git's commit message file: the first suggestion
+// becomes the message, the others follow as comments for copy and paste,
+// and git's own commented template is kept below them. comment is the
+// repository's comment character; empty means DefaultCommentChar.
+func DraftCommitMessage(
+	suggestions []Suggestion,
+	template, comment string,
+) string {
+	if len(suggestions) == 0 {
+		return template
+	}
+	if comment == "" {
+		comment = DefaultCommentChar
+	}
+	var b strings.Builder
+	b.WriteString(suggestions[0].String() + "\n")
+	if len(suggestions) > 1 {
+		b.WriteString("\n" + comment + " Other suggestions from lazycommit:\n")
+		for _, s := range suggestions[1:] {
+			b.WriteString(comment + "\n")
+			for _, line := range strings.Split(s.String(), "\n") {
+				b.WriteString(strings.TrimRight(comment+"   "+line, " ") + "\n")
+			}
+		}
+	}
+	if template != "" && !strings.HasPrefix(template, "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString(template)
+	return b.String()
+}
@@ -0,0 +1,378 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RuleLevel is a commitlint severity: 0 disables a rule, 1 reports it as a
+// warning, 2 as an error.
+type RuleLevel int
+
+const (
+	RuleDisabled RuleLevel = iota
+	RuleWarning
+	RuleError
+)
+
+// RuleSpec is one commitlint rule as configured: name, level,
+// applicability ("always", or "never" when Never is set) and value.
+type RuleSpec struct {
+	Name  string
+	Level RuleLevel
+	Never bool
+	// Value is []string for type-enum and subject-case, int for
+	// header-max-length, and string for subject-full-stop.
+	Value any
+}
+
+// LintViolation is a rule a message still breaks after auto-fixing.
+type LintViolation struct {
+	Rule    string
+	Level   RuleLevel
+	Message string
+}
+
+func (v LintViolation) String() string {
+	return v.Rule + ": " + v.Message
+}
+
+// Supported commitlint rules, in the order they are applied: length comes
+// last because the other fixes change it.
+const (
+	RuleTypeEnum        = "type-enum"
+	RuleSubjectFullStop = "subject-full-stop"
+	RuleSubjectCase     = "subject-case"
+	RuleHeaderMaxLength = "header-max-length"
+)
+
+var ruleOrder = []string{
+	RuleTypeEnum, RuleSubjectFullStop, RuleSubjectCase, RuleHeaderMaxLength,
+}
+
+// LintRules is a validated subset of a commitlint configuration.
+type LintRules struct {
+	rules []lintRule
+}
+
+type lintRule struct {
+	RuleSpec
+	types    []string
+	cases    []string
+	maxLen   int
+	fullStop string
+}
+
+// NewLintRules validates specs. Disabled rules are dropped; unknown rule
+// names and malformed values are errors.
+func NewLintRules(specs []RuleSpec) (LintRules, error) {
+	var rules []lintRule
+	for _, spec := range specs {
+		if spec.Level < RuleDisabled || spec.Level > RuleError {
+			return LintRules{}, fmt.Errorf("%s: level must be 0, 1, or 2", spec.Name)
+		}
+		if spec.Level == RuleDisabled {
+			continue
+		}
+		r := lintRule{RuleSpec: spec}
+		var ok bool
+		switch spec.Name {
+		case RuleTypeEnum:
+			r.types, ok = spec.Value.([]string)
+			ok = ok && len(r.types) > 0
+		case RuleSubjectCase:
+			r.cases, ok = spec.Value.([]string)
+			ok = ok && len(r.cases) > 0
+			for _, c := range r.cases {
+				if _, known := caseCheckers[c]; !known {
+					return LintRules{}, fmt.Errorf("%s: unknown case %q", spec.Name, c)
+				}
+			}
+		case RuleHeaderMaxLength:
+			r.maxLen, ok = spec.Value.(int)
+			ok = ok && r.maxLen > 0
+		case RuleSubjectFullStop:
+			r.fullStop, ok = spec.Value.(string)
+			ok = ok && r.fullStop != ""
+		default:
+			return LintRules{}, fmt.Errorf("unknown rule %q (supported: %s)",
+				spec.Name, strings.Join(ruleOrder, ", "))
+		}
+		if !ok {
+			return LintRules{}, fmt.Errorf("%s: invalid value %v", spec.Name, spec.Value)
+		}
+		rules = append(rules, r)
+	}
+	slices.SortStableFunc(rules, func(a, b lintRule) int {
+		return slices.Index(ruleOrder, a.Name) - slices.Index(ruleOrder, b.Name)
+	})
+	return LintRules{rules: rules}, nil
+}
+
+// Empty reports whether no rule is enabled.
+func (r LintRules) Empty() bool {
+	return len(r.rules) == 0
+}
+
+// Describe renders the enabled rules as instructions for the model.
+func (r LintRules) Describe() []string {
+	var out []string
+	for _, rule := range r.rules {
+		not := ""
+		if rule.Never {
+			not = "not "
+		}
+		switch rule.Name {
+		case RuleTypeEnum:
+			out = append(out, fmt.Sprintf("the type must %sbe one of: %s",
+				not, strings.Join(rule.types, ", ")))
+		case RuleSubjectCase:
+			out = append(out, fmt.Sprintf("the subject must %sbe %s",
+				not, strings.Join(rule.cases, " or ")))
+		case RuleHeaderMaxLength:
+			out = append(out, fmt.Sprintf(
+				"the first line must be at most %d characters", rule.maxLen))
+		case RuleSubjectFullStop:
+			out = append(out, fmt.Sprintf("the subject must %send with %q",
+				not, rule.fullStop))
+		}
+	}
+	return out
+}
+
+// Apply repairs what is mechanically fixable in s (type case, full stop,
+// subject case, over-long subjects cut at a word boundary) and reports the
+// violations that remain. Messages that are not conventional commits are
+// checked on their first line.
+func (r LintRules) Apply(s Suggestion) (Suggestion, []LintViolation) {
+	return r.ApplyPrefixed("", s)
+}
+
+// ApplyPrefixed is Apply for s as it will read behind prefix, such as a
+// ticket label: the prefix counts toward the header length and starts the
+// result, while the rules parse s alone.
+func (r LintRules) ApplyPrefixed(
+	prefix string,
+	s Suggestion,
+) (Suggestion, []LintViolation) {
+	m := newLintMessage(s)
+	m.prefix = prefix
+	var violations []LintViolation
+	for _, rule := range r.rules {
+		if msg := rule.apply(&m); msg != "" {
+			violations = append(violations, LintViolation{
+				Rule: rule.Name, Level: rule.Level, Message: msg,
+			})
+		}
+	}
+	return m.suggestion(s), violations
+}
+
+// lintMessage is the part of a message the rules look at and rewrite.
+type lintMessage struct {
+	prefix  string
+	conv    ConventionalCommit
+	isConv  bool
+	header  string // free-form first line when !isConv
+	rest    string // free-form remainder, including the leading newline
+	changed bool
+}
+
+func newLintMessage(s Suggestion) lintMessage {
+	if c, err := s.Conventional(); err == nil {
+		return lintMessage{conv: c, isConv: true}
+	}
+	header, rest, found := strings.Cut(s.String(), "\n")
+	if found {
+		rest = "\n" + rest
+	}
+	return lintMessage{header: header, rest: rest}
+}
+
+func (m *lintMessage) subject() string {
+	if m.isConv {
+		return m.conv.Subject
+	}
+	return m.header
+}
+
+func (m *lintMessage) setSubject(s string) {
+	m.changed = true
+	if m.isConv {
+		m.conv.Subject = s
+		return
+	}
+	m.header = s
+}
+
+func (m *lintMessage) headerLine() string {
+	if m.isConv {
+		return m.prefix + m.conv.Header()
+	}
+	return m.prefix + m.header
+}
+
+// suggestion renders the message, or returns orig untouched when no fix
+// was applied so unrelated formatting survives.
+func (m *lintMessage) suggestion(orig Suggestion) Suggestion {
+	if !m.changed {
+		return Suggestion{text: m.prefix + orig.text}
+	}
+	if m.isConv {
+		return Suggestion{text: m.prefix + m.conv.String()}
+	}
+	return Suggestion{text: m.prefix + m.header + m.rest}
+}
+
+func (r lintRule) apply(m *lintMessage) string {
+	switch r.Name {
+	case RuleTypeEnum:
+		if !m.isConv {
+			return fmt.Sprintf("%q has no type", m.headerLine())
+		}
+		if slices.Contains(r.types, m.conv.Type) != r.Never {
+			return ""
+		}
+		lower := strings.ToLower(m.conv.Type)
+		if !r.Never && slices.Contains(r.types, lower) {
+			m.conv.Type = lower
+			m.changed = true
+			return ""
+		}
+		return fmt.Sprintf("%q has type %q", m.headerLine(), m.conv.Type)
+
+	case RuleSubjectFullStop:
+		subject := m.subject()
+		if strings.HasSuffix(subject, r.fullStop) != r.Never {
+			return ""
+		}
+		if r.Never {
+			m.setSubject(strings.TrimRight(strings.TrimSuffix(subject, r.fullStop), " "))
+		} else {
+			m.setSubject(subject + r.fullStop)
+		}
+		return ""
+
+	case RuleSubjectCase:
+		subject := m.subject()
+		if r.caseOK(subject) {
+			return ""
+		}
+		for _, candidate := range r.caseFixes(subject) {
+			if r.caseOK(candidate) {
+				m.setSubject(candidate)
+				return ""
+			}
+		}
+		return fmt.Sprintf("%q has the wrong subject case", m.headerLine())
+
+	case RuleHeaderMaxLength:
+		header := m.headerLine()
+		over := utf8.RuneCountInString(header) - r.maxLen
+		if over <= 0 {
+			return ""
+		}
+		subject := []rune(m.subject())
+		if keep := len(subject) - over; keep > 0 {
+			cut := string(subject[:keep])
+			if subject[keep] != ' ' {
+				i := strings.LastIndexByte(cut, ' ')
+				cut = cut[:max(i, 0)]
+			}
+			if cut = strings.TrimRight(cut, " ,;:-"); cut != "" {
+				m.setSubject(cut)
+				return ""
+			}
+		}
+		return fmt.Sprintf("%q is longer than %d characters", header, r.maxLen)
+	}
+	return ""
+}
+
+func (r lintRule) caseOK(subject string) bool {
+	matched := false
+	for _, c := range r.cases {
+		if caseCheckers[c](subject) {
+			matched = true
+			break
+		}
+	}
+	return matched != r.Never
+}
+
+// caseFixes lists rewrites to try, in order of least disruption.
+func (r lintRule) caseFixes(subject string) []string {
+	if r.Never {
+		return []string{
+			lowerFirst(subject), strings.ToLower(subject), upperFirst(subject),
+		}
+	}
+	var out []string
+	for _, c := range r.cases {
+		switch c {
+		case "lower-case":
+			out = append(out, strings.ToLower(subject))
+		case "upper-case":
+			out = append(out, strings.ToUpper(subject))
+		case "sentence-case":
+			out = append(out, upperFirst(subject))
+		}
+	}
+	return out
+}
+
+var (
+	kebabCase = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	snakeCase = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+)
+
+// caseCheckers follow commitlint's case names. Sentence case means a
+// capitalized first letter; start case capitalizes every word.
+var caseCheckers = map[string]func(string) bool{
+	"lower-case": func(s string) bool { return s == strings.ToLower(s) },
+	"upper-case": func(s string) bool { return s == strings.ToUpper(s) },
+	"sentence-case": func(s string) bool {
+		return s == upperFirst(s) && s != strings.ToLower(s) && s != strings.ToUpper(s)
+	},
+	"start-case": func(s string) bool {
+		for _, w := range strings.Fields(s) {
+			if w != upperFirst(w) {
+				return false
+			}
+		}
+		return s != strings.ToLower(s)
+	},
+	"pascal-case": func(s string) bool {
+		return isIdentifier(s) && s == upperFirst(s) && s != strings.ToLower(s)
+	},
+	"camel-case": func(s string) bool {
+		first, _ := utf8.DecodeRuneInString(s)
+		return isIdentifier(s) && !unicode.IsUpper(first)
+	},
+	"kebab-case": kebabCase.MatchString,
+	"snake-case": snakeCase.MatchString,
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func upperFirst(s string) string {
+	first, size := utf8.DecodeRuneInString(s)
+	if first == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(first)) + s[size:]
+}
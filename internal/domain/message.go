@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MessageSeparator is the line the model is asked to put between full
+	// commit messages, and the separator used when printing them. Unlike a
+	// markdown rule, it cannot turn up in a body by accident.
+	MessageSeparator = "=== next message ==="
+
+	// BodyWrapWidth is the column commit bodies are wrapped at.
+	BodyWrapWidth = 72
+
+	// MaxMessageLength bounds a full multi-line message.
+	MaxMessageLength = 5000
+)
+
+// NewMessage returns a multi-line suggestion: a subject line no longer than
+// MaxSuggestionLength, then optionally a blank line and a body.
+func NewMessage(text string) (Suggestion, error) {
+	trimmed := strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if trimmed == "" {
+		return Suggestion{}, errors.New("message is empty")
+	}
+	if len(trimmed) > MaxMessageLength {
+		return Suggestion{}, errors.New("message exceeds maximum length")
+	}
+	subject, rest, _ := strings.Cut(trimmed, "\n")
+	if len(subject) > MaxSuggestionLength {
+		return Suggestion{}, errors.New("subject line exceeds maximum length")
+	}
+	if rest != "" && !strings.HasPrefix(rest, "\n") {
+		return Suggestion{}, errors.New(
+			"body must be separated from the subject by a blank line")
+	}
+	return Suggestion{text: trimmed}, nil
+}
+
+// ParseMessages splits raw LLM output into at most max full commit
+// messages. Records are separated by MessageSeparator lines, so blank lines
+// and markdown rules inside a message survive. A code fence around the
+// whole output and list markers on the subject line are stripped, a
+// missing blank line after the subject is added, and prose in bodies is
+// re-wrapped at BodyWrapWidth.
+func ParseMessages(raw string, max int) []Suggestion {
+	if max <= 0 {
+		return nil
+	}
+
+	var result []Suggestion
+	for _, record := range splitRecords(raw) {
+		text := cleanMessage(record)
+		if text == "" {
+			continue
+		}
+		if s, err := NewMessage(text); err == nil {
+			result = append(result, s)
+		}
+		if len(result) >= max {
+			break
+		}
+	}
+	return result
+}
+
+func splitRecords(raw string) []string {
+	raw = strings.Trim(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	lines := strings.Split(raw, "\n")
+	if n := len(lines); n >= 2 &&
+		strings.HasPrefix(strings.TrimSpace(lines[0]), "```") &&
+		isCodeFence(strings.TrimSpace(lines[n-1])) {
+		lines = lines[1 : n-1]
+	}
+	var records []string
+	var cur []string
+	for _, line := range lines {
+		if strings.EqualFold(strings.TrimSpace(line), MessageSeparator) {
+			records = append(records, strings.Join(cur, "\n"))
+			cur = nil
+			continue
+		}
+		cur = append(cur, strings.TrimRight(line, " \t"))
+	}
+	return append(records, strings.Join(cur, "\n"))
+}
+
+func cleanMessage(record string) string {
+	record = strings.Trim(record, "\n")
+	subject, rest, _ := strings.Cut(record, "\n")
+	subject = stripListPrefix(strings.TrimSpace(subject))
+	if subject == "" {
+		return ""
+	}
+	rest = strings.Trim(rest, "\n")
+	if rest == "" {
+		return subject
+	}
+	body, footers := splitFooters(rest)
+	parts := []string{subject}
+	if body != "" {
+		parts = append(parts, WrapBody(body, BodyWrapWidth))
+	}
+	if len(footers) > 0 {
+		lines := make([]string, len(footers))
+		for i, f := range footers {
+			lines[i] = f.String()
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// listItem matches a markdown bullet or numbered list item.
+var listItem = regexp.MustCompile(`^([-*+]|\d+[.)])[ \t]`)
+
+// WrapBody re-flows the prose paragraphs of body to width columns. Fenced
+// and indented code, tables, and list continuation lines are kept as
+// written; a list item keeps its own lines and only an over-long one wraps,
+// with a hanging indent. Words longer than width (URLs) are never split.
+func WrapBody(body string, width int) string {
+	var out, pending []string
+	flush := func() {
+		if len(pending) > 0 {
+			words := strings.Fields(strings.Join(pending, " "))
+			out = append(out, wrapWords(words, width, "")...)
+			pending = nil
+		}
+	}
+	fence := ""
+	for _, line := range strings.Split(strings.Trim(body, "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			out = append(out, line)
+			if strings.HasPrefix(trimmed, fence) &&
+				strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			fence = trimmed[:3]
+			out = append(out, line)
+		case trimmed == "":
+			flush()
+			out = append(out, "")
+		case listItem.MatchString(trimmed):
+			flush()
+			out = append(out, wrapItem(line, width)...)
+		case strings.HasPrefix(trimmed, "|"),
+			len(pending) == 0 && (line[0] == ' ' || line[0] == '\t'):
+			// Tables, code, and list continuations; indented lines inside
+			// a paragraph are prose.
+			flush()
+			out = append(out, line)
+		default:
+			pending = append(pending, trimmed)
+		}
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+// wrapItem wraps a list item longer than width, indenting continuation
+// lines past its marker; shorter items are returned as they are.
+func wrapItem(line string, width int) []string {
+	if len(line) <= width {
+		return []string{line}
+	}
+	trimmed := strings.TrimLeft(line, " \t")
+	lead := line[:len(line)-len(trimmed)]
+	marker, _, _ := strings.Cut(trimmed, " ")
+	indent := strings.Repeat(" ", len(marker)+1)
+	lines := wrapWords(strings.Fields(trimmed), width-len(lead), indent)
+	for i := range lines {
+		lines[i] = lead + lines[i]
+	}
+	return lines
+}
+
+// wrapWords fills lines up to width; continuation lines start with indent.
+func wrapWords(words []string, width int, indent string) []string {
+	var lines []string
+	var line strings.Builder
+	for _, w := range words {
+		switch {
+		case line.Len() == 0:
+			if len(lines) > 0 {
+				line.WriteString(indent)
+			}
+			line.WriteString(w)
+		case line.Len()+1+len(w) > width:
+			lines = append(lines, line.String())
+			line.Reset()
+			line.WriteString(indent + w)
+		default:
+			line.WriteString(" " + w)
+		}
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// ParseDocument takes raw LLM output as one markdown document, such as a
+// pull request description, dropping a code fence wrapped around all of
+// it (with or without a language tag). ok is false when nothing is left.
+func ParseDocument(raw string) (s Suggestion, ok bool) {
+	text := strings.TrimSpace(strings.ReplaceAll(raw, "\r\n", "\n"))
+	lines := strings.Split(text, "\n")
+	if len(lines) >= 2 && strings.HasPrefix(lines[0], "```") &&
+		strings.TrimSpace(lines[len(lines)-1]) == "```" {
+		text = strings.TrimSpace(strings.Join(lines[1:len(lines)-1], "\n"))
+	}
+	if text == "" {
+		return Suggestion{}, false
+	}
+	return Suggestion{text: text}, true
+}
@@ -14,10 +14,46 @@ const (
 	DefaultCommitTemplate = "Based on the following git diff, generate conventional commit messages. " +
 		"Each message must be on its own line, without any numbering, bullet points, or markdown formatting:\n\n%s"
 
+	DefaultCommitBodyTemplate = "Based on the following git diff, generate conventional commit messages " +
+		"whose body explains why the change was made, without numbering or markdown formatting:\n\n%s"
+
+	DefaultSquashTemplate = "The following git diff is the combined change of several commits being squashed into one. " +
+		"Write a conventional commit message whose subject sums up the whole change and whose body " +
+		"lists the notable changes as \"- \" items, without markdown headings:\n\n%s"
+
+	DefaultPRBodyTemplate = "Based on the following git diff, write a pull request description in markdown " +
+		"with the sections \"## Summary\", \"## Motivation\", \"## Changes\" (a bullet list), and \"## Testing\":\n\n%s"
+
+	DefaultBranchTemplate = "Based on the following git diff, suggest short git branch names of two to five words " +
+		"describing the change. Write each in kebab-case on its own line, without a type prefix such as feat/, " +
+		"ticket numbers, numbering, or markdown formatting:\n\n%s"
+
+	DefaultChangelogTemplate = "Write changelog entries for the commits below, which all belong in the section " +
+		"named on the first line. Describe each change users will notice in one plain sentence, merge commits " +
+		"that make the same change, and leave out purely internal ones. Answer with a \"- \" bullet list only:\n\n%s"
+
 	DefaultPRTitleTemplate = "Based on the following git diff, generate pull request title suggestions. " +
 		"Each title must be on its own line, without any numbering, bullet points, or markdown formatting:\n\n%s"
 
 	DefaultSuggestionCount = 10
+
+	// ExcludedOnlyDiff stands in for the patch when every changed file
+	// matched an exclusion glob.
+	ExcludedOnlyDiff = "(no diff shown: every changed file matched an exclusion pattern)"
+
+	// maxExcludedListed caps the "also updated" note so a mass lockfile
+	// change does not reintroduce the bloat exclusion removed.
+	maxExcludedListed = 20
+
+	// ChunkSummaryTemplate takes the chunk's directory and its diff.
+	ChunkSummaryTemplate = "The following is the part of a larger git diff touching %s. " +
+		"Summarize what it changes in at most three short sentences of plain prose, " +
+		"without markdown and without proposing commit messages:\n\n%s"
+
+	// BumpClassifyTemplate takes a commit message and its diff.
+	BumpClassifyTemplate = "Classify the commit below for a semantic version bump: \"major\" if it breaks " +
+		"existing users, \"minor\" if it adds functionality, \"patch\" if it fixes a bug, or \"none\" if users " +
+		"cannot notice it. Answer with one line, \"<level>: <short reason>\".\n\n%s\n\n%s"
 )
 
 // PromptTemplate is a user-facing text template with a single %s placeholder
@@ -53,6 +89,19 @@ type PromptBuilder struct {
 	template PromptTemplate
 	language Language
 	count    int
+	excluded []string
+	changes  []FileChange
+	examples []string
+	scopes   []string
+	inferred []string
+	messages bool
+	rules    []string
+	rejected []string
+	hint     string
+	avoid    []string
+	log      []string
+	document bool
+	layout   string
 }
 
 func NewPromptBuilder() *PromptBuilder {
@@ -89,10 +138,147 @@ func (b *PromptBuilder) WithSuggestionCount(n int) *PromptBuilder {
 	return b
 }
 
+// WithExcludedPaths names changed files left out of the diff, so the
+// message can still mention them.
+func (b *PromptBuilder) WithExcludedPaths(paths []string) *PromptBuilder {
+	b.excluded = paths
+	return b
+}
+
+// WithChanges adds a file table (status and line counts) ahead of the diff.
+func (b *PromptBuilder) WithChanges(changes []FileChange) *PromptBuilder {
+	b.changes = changes
+	return b
+}
+
+// WithStyleExamples shows recent commit subjects so suggestions follow the
+// repository's own conventions.
+func (b *PromptBuilder) WithStyleExamples(subjects []string) *PromptBuilder {
+	b.examples = subjects
+	return b
+}
+
+// WithScopes restricts conventional-commit scopes to allowed and names the
+// ones the changed files map to.
+func (b *PromptBuilder) WithScopes(allowed, inferred []string) *PromptBuilder {
+	b.scopes = allowed
+	b.inferred = inferred
+	return b
+}
+
+// WithFullMessages asks for complete multi-line commit messages, separated
+// by MessageSeparator lines, instead of one subject per line.
+func (b *PromptBuilder) WithFullMessages(on bool) *PromptBuilder {
+	b.messages = on
+	return b
+}
+
+// WithRules lists commit message rules the suggestions must follow.
+func (b *PromptBuilder) WithRules(rules []string) *PromptBuilder {
+	b.rules = rules
+	return b
+}
+
+// WithRejected lists rule violations of an earlier attempt, so a retry
+// does not repeat them.
+func (b *PromptBuilder) WithRejected(violations []string) *PromptBuilder {
+	b.rejected = violations
+	return b
+}
+
+// WithHint adds free-form guidance from the user.
+func (b *PromptBuilder) WithHint(hint string) *PromptBuilder {
+	b.hint = strings.TrimSpace(hint)
+	return b
+}
+
+// WithAvoid lists earlier suggestions the new ones must differ from.
+func (b *PromptBuilder) WithAvoid(previous []string) *PromptBuilder {
+	b.avoid = previous
+	return b
+}
+
+// WithCommitLog lists the subjects of the commits behind the diff, oldest
+// first.
+func (b *PromptBuilder) WithCommitLog(subjects []string) *PromptBuilder {
+	b.log = subjects
+	return b
+}
+
+// WithDocument asks for a single markdown document instead of a list of
+// suggestions.
+func (b *PromptBuilder) WithDocument(on bool) *PromptBuilder {
+	b.document = on
+	return b
+}
+
+// WithLayout gives a document the sections of a repository template, such
+// as .github/pull_request_template.md.
+func (b *PromptBuilder) WithLayout(layout string) *PromptBuilder {
+	b.layout = strings.TrimSpace(layout)
+	return b
+}
+
 func (b *PromptBuilder) Build(diff Diff) Prompt {
 	var user strings.Builder
-	fmt.Fprintf(&user, b.template.String(), diff.String())
-	fmt.Fprintf(&user, "\n\nGenerate exactly %d suggestions.", b.count)
+	fmt.Fprintf(&user, b.template.String(), changeTable(b.changes)+diff.String())
+	if len(b.excluded) > 0 {
+		listed := b.excluded
+		more := ""
+		if len(listed) > maxExcludedListed {
+			listed = listed[:maxExcludedListed]
+			more = fmt.Sprintf(" and %d more", len(b.excluded)-maxExcludedListed)
+		}
+		fmt.Fprintf(&user, "\n\nAlso updated (not shown in the diff): %s%s.", strings.Join(listed, ", "), more)
+	}
+	if len(b.log) > 0 {
+		user.WriteString("\n\nCommits included in this change, oldest first:\n- ")
+		user.WriteString(strings.Join(b.log, "\n- "))
+	}
+	if len(b.examples) > 0 {
+		user.WriteString("\n\nRecent commit messages in this repository. Match their style and conventions, not their content:\n")
+		user.WriteString(strings.Join(b.examples, "\n"))
+	}
+	if len(b.scopes) > 0 {
+		fmt.Fprintf(&user, "\n\nUse only these conventional commit scopes: %s.", strings.Join(b.scopes, ", "))
+		if len(b.inferred) > 0 {
+			fmt.Fprintf(&user, " The changed files belong to: %s.", strings.Join(b.inferred, ", "))
+		}
+	}
+	if len(b.rules) > 0 {
+		user.WriteString("\n\nEvery suggestion must follow these rules:\n- ")
+		user.WriteString(strings.Join(b.rules, "\n- "))
+	}
+	if len(b.rejected) > 0 {
+		user.WriteString("\n\nAn earlier attempt was rejected for breaking them:\n- ")
+		user.WriteString(strings.Join(b.rejected, "\n- "))
+	}
+	if b.hint != "" {
+		fmt.Fprintf(&user, "\n\nGuidance from the user: %s", b.hint)
+	}
+	if len(b.avoid) > 0 {
+		user.WriteString("\n\nThese were already suggested; propose different ones:\n")
+		user.WriteString(strings.Join(b.avoid, "\n"))
+	}
+	if b.messages {
+		fmt.Fprintf(&user, "\n\nEach suggestion is a complete commit message: a subject line, a blank line, "+
+			"then a body wrapped at %d columns, optionally followed by footers such as \"BREAKING CHANGE: ...\" or \"Refs: ...\". "+
+			"Put a line containing only %s between suggestions.", BodyWrapWidth, MessageSeparator)
+	}
+	if b.document {
+		if b.layout != "" {
+			user.WriteString("\n\nLay it out like this repository's pull request template instead of any sections named above: " +
+				"keep its headings and checklists, and replace its comments and placeholder text:\n\n")
+			user.WriteString(b.layout)
+		}
+		fmt.Fprintf(&user, "\n\nReply with the markdown document only, written in %s.", b.language)
+		return Prompt{System: b.system, User: user.String()}
+	}
+	if b.count == 1 {
+		user.WriteString("\n\nGenerate exactly 1 suggestion.")
+	} else {
+		fmt.Fprintf(&user, "\n\nGenerate exactly %d suggestions.", b.count)
+	}
 	fmt.Fprintf(&user, " Write every suggestion in %s.", b.language)
 	return Prompt{System: b.system, User: user.String()}
 }
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ScopeMap maps repository-relative path globs to conventional-commit
+// scopes. In globs, "*" and "?" stay within one path segment and "**"
+// spans any number of them.
+type ScopeMap struct {
+	rules []scopeRule
+}
+
+type scopeRule struct {
+	glob  string
+	scope string
+	re    *regexp.Regexp
+}
+
+// NewScopeMap compiles globs to scopes. Rules are kept sorted by glob so
+// results never depend on map iteration order.
+func NewScopeMap(globs map[string]string) (ScopeMap, error) {
+	var m ScopeMap
+	for glob, scope := range globs {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			return ScopeMap{}, fmt.Errorf("glob %q has an empty scope", glob)
+		}
+		re, err := compileGlob(glob)
+		if err != nil {
+			return ScopeMap{}, fmt.Errorf("glob %q: %w", glob, err)
+		}
+		m.rules = append(m.rules, scopeRule{glob: glob, scope: scope, re: re})
+	}
+	sort.Slice(m.rules, func(i, j int) bool { return m.rules[i].glob < m.rules[j].glob })
+	return m, nil
+}
+
+// Empty reports whether no scopes are configured.
+func (m ScopeMap) Empty() bool {
+	return len(m.rules) == 0
+}
+
+// Allowed returns every configured scope, sorted and deduplicated.
+func (m ScopeMap) Allowed() []string {
+	scopes := make([]string, 0, len(m.rules))
+	for _, r := range m.rules {
+		scopes = append(scopes, r.scope)
+	}
+	return uniqueSorted(scopes)
+}
+
+// Infer returns the scopes whose globs match any of paths, sorted.
+func (m ScopeMap) Infer(paths []string) []string {
+	var scopes []string
+	for _, r := range m.rules {
+		for _, p := range paths {
+			if r.re.MatchString(p) {
+				scopes = append(scopes, r.scope)
+				break
+			}
+		}
+	}
+	return uniqueSorted(scopes)
+}
+
+// Enforce checks a conventional commit suggestion's scope against the
+// allowed set. A disallowed or missing scope is rewritten when the change
+// set points at exactly one scope; a disallowed scope is otherwise
+// rejected. Suggestions that are not conventional commits pass through.
+func (m ScopeMap) Enforce(s Suggestion, inferred []string) (Suggestion, bool) {
+	c, err := s.Conventional()
+	if err != nil || m.Empty() {
+		return s, true
+	}
+	for _, allowed := range m.Allowed() {
+		if c.Scope == allowed {
+			return s, true
+		}
+	}
+	if len(inferred) != 1 {
+		return s, c.Scope == ""
+	}
+	c.Scope = inferred[0]
+	return Suggestion{text: c.String()}, true
+}
+
+// compileGlob translates a path glob into an anchored regular expression.
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func uniqueSorted(in []string) []string {
+	sort.Strings(in)
+	out := in[:0]
+	for i, s := range in {
+		if i == 0 || s != in[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReleaseImpact is how far a change moves a semantic version.
+type ReleaseImpact int
+
+const (
+	ImpactNone ReleaseImpact = iota
+	ImpactPatch
+	ImpactMinor
+	ImpactMajor
+)
+
+var impactNames = []string{"none", "patch", "minor", "major"}
+
+func (i ReleaseImpact) String() string {
+	if i < ImpactNone || i > ImpactMajor {
+		return fmt.Sprintf("ReleaseImpact(%d)", int(i))
+	}
+	return impactNames[i]
+}
+
+// ConventionalImpact classifies a conventional commit message: breaking
+// changes are major, feat is minor, fix and perf are patch, and other
+// types need no release. ok is false for messages that are not
+// conventional commits.
+func ConventionalImpact(message string) (impact ReleaseImpact, ok bool) {
+	c, err := ParseConventionalCommit(message)
+	if err != nil {
+		return ImpactNone, false
+	}
+	switch {
+	case c.Breaking:
+		return ImpactMajor, true
+	case strings.EqualFold(c.Type, "feat"):
+		return ImpactMinor, true
+	case strings.EqualFold(c.Type, "fix"), strings.EqualFold(c.Type, "perf"):
+		return ImpactPatch, true
+	}
+	return ImpactNone, true
+}
+
+// NewBumpClassifyPrompt asks how far a commit that is not a conventional
+// commit moves the version, for ParseImpactAnswer to read.
+func NewBumpClassifyPrompt(system, message string, diff Diff) Prompt {
+	if strings.TrimSpace(system) == "" {
+		system = DefaultSystemMessage
+	}
+	user := fmt.Sprintf(BumpClassifyTemplate, message, diff.String())
+	return Prompt{System: system, User: user}
+}
+
+// ParseImpactAnswer reads a model's "<impact>: <reason>" answer from the
+// first line that starts with an impact word, skipping any preamble. The
+// impact word may be wrapped in markdown emphasis.
+func ParseImpactAnswer(raw string) (ReleaseImpact, string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return ImpactNone, "", errors.New("empty answer")
+	}
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(strings.TrimPrefix(line, "- "), "* ")
+		if line == "" || isCodeFence(line) {
+			continue
+		}
+		word, reason, _ := strings.Cut(line, ":")
+		word = strings.ToLower(strings.Trim(word, "*_` "))
+		for i, name := range impactNames {
+			if word == name {
+				return ReleaseImpact(i), strings.TrimSpace(reason), nil
+			}
+		}
+	}
+	return ImpactNone, "", fmt.Errorf("unexpected answer %q", strings.TrimSpace(raw))
+}
+
+// semverTag matches release tags such as v1.2.3 or 1.2.3; pre-releases
+// and build metadata are not releases to bump from.
+var semverTag = regexp.MustCompile(`^(v?)(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)$`)
+
+// Version is a released semantic version and the tag prefix it was
+// written with ("v" or "").
+type Version struct {
+	Prefix              string
+	Major, Minor, Patch int
+}
+
+// ParseVersion reads a release tag; ok is false for anything else.
+func ParseVersion(tag string) (Version, bool) {
+	m := semverTag.FindStringSubmatch(tag)
+	if m == nil {
+		return Version{}, false
+	}
+	v := Version{Prefix: m[1]}
+	v.Major, _ = strconv.Atoi(m[2])
+	v.Minor, _ = strconv.Atoi(m[3])
+	v.Patch, _ = strconv.Atoi(m[4])
+	return v, true
+}
+
+// LatestVersion returns the highest release tag among tags.
+func LatestVersion(tags []string) (Version, bool) {
+	var latest Version
+	found := false
+	for _, tag := range tags {
+		v, ok := ParseVersion(strings.TrimSpace(tag))
+		if ok && (!found || latest.Less(v)) {
+			latest, found = v, true
+		}
+	}
+	return latest, found
+}
+
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+// Bump returns the next version for impact; ImpactNone returns v.
+func (v Version) Bump(impact ReleaseImpact) Version {
+	switch impact {
+	case ImpactMajor:
+		return Version{Prefix: v.Prefix, Major: v.Major + 1}
+	case ImpactMinor:
+		return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor + 1}
+	case ImpactPatch:
+		return Version{
+			Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1,
+		}
+	}
+	return v
+}
+
+// String renders the tag, prefix included.
+func (v Version) String() string {
+	return fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+}
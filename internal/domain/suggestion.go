@@ -9,7 +9,8 @@ import (
 // prose or a stray diff fragment, not a usable commit message or PR title.
 const MaxSuggestionLength = 200
 
-// Suggestion is a single non-empty, single-line generated message.
+// Suggestion is a single non-empty generated message: one line from
+// NewSuggestion, or a full commit message with a body from NewMessage.
 type Suggestion struct {
 	text string
 }
@@ -31,3 +32,32 @@ func NewSuggestion(text string) (Suggestion, error) {
 func (s Suggestion) String() string {
 	return s.text
 }
+
+// Subject returns the first line of the message.
+func (s Suggestion) Subject() string {
+	subject, _, _ := strings.Cut(s.text, "\n")
+	return subject
+}
+
+// Conventional parses the suggestion as a Conventional Commits message,
+// exposing its type, scope, breaking flag, subject, body, and footers.
+func (s Suggestion) Conventional() (ConventionalCommit, error) {
+	return ParseConventionalCommit(s.text)
+}
+
+// NormalizeConventional keeps only the suggestions that parse as
+// conventional commits, each normalized; order is preserved.
+func NormalizeConventional(suggestions []Suggestion) []Suggestion {
+	var out []Suggestion
+	for _, s := range suggestions {
+		c, err := s.Conventional()
+		if err != nil {
+			continue
+		}
+		if c = c.Normalize(); c.Subject == "" {
+			continue
+		}
+		out = append(out, Suggestion{text: c.String()})
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultTicketFormat renders the ticket prefix when none is configured.
+const DefaultTicketFormat = "%s: "
+
+// Ticket is an issue-tracker key, such as PAY-1234, taken from a branch
+// name.
+type Ticket struct {
+	key string
+	// existing matches a copy of the key at the front of a message.
+	existing *regexp.Regexp
+}
+
+func newTicket(key string) Ticket {
+	existing := regexp.MustCompile(
+		`(?i)^[\[(]?` + regexp.QuoteMeta(key) + `[\])]?[\s:-]*`)
+	return Ticket{key: key, existing: existing}
+}
+
+// ExtractTicket matches pattern against branch. The first capture group is
+// the key when the pattern has one, otherwise the whole match.
+func ExtractTicket(pattern *regexp.Regexp, branch string) (Ticket, bool) {
+	if pattern == nil || branch == "" {
+		return Ticket{}, false
+	}
+	m := pattern.FindStringSubmatch(branch)
+	if m == nil {
+		return Ticket{}, false
+	}
+	key := m[0]
+	if len(m) > 1 {
+		key = m[1]
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return Ticket{}, false
+	}
+	return newTicket(key), true
+}
+
+// NewTicket wraps an explicit ticket key, such as one given on the command
+// line.
+func NewTicket(key string) (Ticket, bool) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return Ticket{}, false
+	}
+	return newTicket(key), true
+}
+
+func (t Ticket) String() string {
+	return t.key
+}
+
+// ValidateTicketFormat checks that format has exactly one %s for the key.
+func ValidateTicketFormat(format string) error {
+	if strings.Count(format, "%s") != 1 {
+		return errors.New("ticket format must contain exactly one %s " +
+			"placeholder for the ticket key")
+	}
+	return nil
+}
+
+// Prefix returns s starting with the formatted ticket exactly once. A copy
+// of the key the model already put at the front ("PAY-1: ", "[PAY-1] ") is
+// removed first, so the result never depends on whether the model followed
+// instructions.
+func (t Ticket) Prefix(s Suggestion, format string) Suggestion {
+	return Suggestion{text: strings.TrimSpace(t.Label(format) + t.Strip(s).text)}
+}
+
+// Label is the ticket rendered with format, as Prefix puts it in front.
+func (t Ticket) Label(format string) string {
+	return strings.TrimLeft(fmt.Sprintf(format, t.key), " \t")
+}
+
+// Strip removes a copy of the key from the front of s, so the rest can be
+// checked as a conventional commit.
+func (t Ticket) Strip(s Suggestion) Suggestion {
+	if t.existing == nil {
+		return s
+	}
+	return Suggestion{text: t.existing.ReplaceAllString(s.text, "")}
+}
+
+// ticketKey matches the issue keys ticket formats are filled with:
+// "PAY-12", "#12", or a bare number.
+const ticketKey = `(?:[A-Za-z][A-Za-z0-9_]*-\d+|#?\d+)`
+
+// TicketPrefix recognizes the ticket prefix a ticket format writes, so
+// ticketed history can be read as conventional commits. The zero value
+// recognizes nothing.
+type TicketPrefix struct {
+	re *regexp.Regexp
+}
+
+// NewTicketPrefix matches format, such as "%s: " or "[%s] ", filled with
+// any issue key. An empty format means DefaultTicketFormat.
+func NewTicketPrefix(format string) TicketPrefix {
+	if format == "" {
+		format = DefaultTicketFormat
+	}
+	before, after, ok := strings.Cut(format, "%s")
+	if !ok {
+		return TicketPrefix{}
+	}
+	before = regexp.QuoteMeta(strings.TrimLeft(before, " \t"))
+	after = regexp.QuoteMeta(strings.TrimRight(after, " \t"))
+	return TicketPrefix{re: regexp.MustCompile(`^` + before + ticketKey + after + `\s*`)}
+}
+
+// Strip returns message without a leading ticket prefix.
+func (p TicketPrefix) Strip(message string) string {
+	if p.re == nil {
+		return message
+	}
+	return p.re.ReplaceAllString(strings.TrimSpace(message), "")
+}
@@ -1,33 +1,301 @@
-// Package git implements the DiffSource port by shelling out to the git CLI.
+// Package git implements the DiffSource, CommitWriter, HistoryRewriter,
+// BranchCreator, and HookInstaller ports by shelling out to the git CLI.
 package git
 
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+	"github.com/m7medvision/lazycommit/internal/domain"
 )
 
-// CLI reads diffs from the repository containing the working directory.
+// CLI reads diffs from, and writes commits to, the repository containing
+// the working directory.
 type CLI struct{}
 
 func New() *CLI {
 	return &CLI{}
 }
 
-// StagedDiff returns `git diff --cached`; empty output means nothing staged.
-func (c *CLI) StagedDiff(ctx context.Context) (string, error) {
-	return run(ctx, "diff", "--cached")
+// StagedDiff returns `git diff --cached`; an empty patch with nothing
+// excluded means nothing staged.
+func (c *CLI) StagedDiff(ctx context.Context, exclude []string) (app.RawDiff, error) {
+	return diff(ctx, []string{"--cached"}, exclude)
 }
 
 // BranchDiff returns the merge-base diff between target and HEAD — the same
 // changes a pull request against target would show.
-func (c *CLI) BranchDiff(ctx context.Context, target string) (string, error) {
+func (c *CLI) BranchDiff(
+	ctx context.Context,
+	target string,
+	exclude []string,
+) (app.RawDiff, error) {
+	if _, err := run(ctx, "rev-parse", "--verify", target); err != nil {
+		return app.RawDiff{}, fmt.Errorf("branch %q does not exist", target)
+	}
+	return diff(ctx, []string{target + "...HEAD"}, exclude)
+}
+
+// WorkingTreeDiff returns `git diff`: changes to tracked files that are
+// not staged yet.
+func (c *CLI) WorkingTreeDiff(
+	ctx context.Context,
+	exclude []string,
+) (app.RawDiff, error) {
+	return diff(ctx, nil, exclude)
+}
+
+// BranchLog returns the subjects of the non-merge commits in target..HEAD,
+// oldest first.
+func (c *CLI) BranchLog(ctx context.Context, target string) ([]string, error) {
 	if _, err := run(ctx, "rev-parse", "--verify", target); err != nil {
-		return "", fmt.Errorf("branch %q does not exist", target)
+		return nil, fmt.Errorf("branch %q does not exist", target)
+	}
+	out, err := run(ctx, "log", "--reverse", "--no-merges", "--format=%s",
+		target+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var subjects []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			subjects = append(subjects, line)
+		}
+	}
+	return subjects, nil
+}
+
+// diff runs `git diff <spec>` with the exclude globs as pathspecs, reads
+// the change set for the same files, then lists which changed paths the
+// globs held back. Pathspecs are anchored at the top level so results do
+// not depend on the working directory.
+func diff(ctx context.Context, spec, exclude []string) (app.RawDiff, error) {
+	base := append([]string{"diff"}, spec...)
+	withPaths := func(extra ...string) []string {
+		args := append(append(append([]string{}, base...), extra...), "--")
+		if len(exclude) == 0 {
+			return args
+		}
+		args = append(args, ":(top)")
+		for _, glob := range exclude {
+			args = append(args, ":(top,glob,exclude)"+glob)
+		}
+		return args
+	}
+
+	patch, err := run(ctx, withPaths()...)
+	if err != nil {
+		return app.RawDiff{}, err
+	}
+	nameStatus, err := run(ctx, withPaths("--name-status", "-M", "-z")...)
+	if err != nil {
+		return app.RawDiff{}, err
+	}
+	numstat, err := run(ctx, withPaths("--numstat", "-M", "-z")...)
+	if err != nil {
+		return app.RawDiff{}, err
+	}
+	changes, err := parseChanges(nameStatus, numstat)
+	if err != nil {
+		return app.RawDiff{}, err
+	}
+	out := app.RawDiff{Patch: patch, Changes: changes}
+	if len(exclude) == 0 {
+		return out, nil
+	}
+
+	nameArgs := append(append([]string{}, base...), "--name-only", "-z", "--")
+	for _, glob := range exclude {
+		nameArgs = append(nameArgs, ":(top,glob)"+glob)
+	}
+	names, err := run(ctx, nameArgs...)
+	if err != nil {
+		return app.RawDiff{}, err
+	}
+	for _, name := range strings.Split(names, "\x00") {
+		if name != "" {
+			out.Excluded = append(out.Excluded, name)
+		}
+	}
+	return out, nil
+}
+
+// parseChanges joins `--name-status -z` and `--numstat -z` output into one
+// change set, in name-status order.
+func parseChanges(nameStatus, numstat string) ([]domain.FileChange, error) {
+	var changes []domain.FileChange
+	index := make(map[string]int)
+
+	fields := strings.Split(strings.TrimSuffix(nameStatus, "\x00"), "\x00")
+	for i := 0; i < len(fields) && fields[i] != ""; {
+		status := fields[i]
+		c := domain.FileChange{Status: domain.ChangeStatus(status[:1])}
+		if c.Status == domain.ChangeRenamed || c.Status == domain.ChangeCopied {
+			if i+2 >= len(fields) {
+				return nil, fmt.Errorf("malformed name-status output near %q", status)
+			}
+			c.OldPath, c.Path = fields[i+1], fields[i+2]
+			i += 3
+		} else {
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("malformed name-status output near %q", status)
+			}
+			c.Path = fields[i+1]
+			i += 2
+		}
+		index[c.Path] = len(changes)
+		changes = append(changes, c)
+	}
+
+	// numstat -z: "added\tdeleted\tpath\0", or for renames and copies
+	// "added\tdeleted\t\0old\0new\0".
+	fields = strings.Split(numstat, "\x00")
+	for i := 0; i < len(fields); i++ {
+		parts := strings.SplitN(fields[i], "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		path := parts[2]
+		if path == "" && i+2 < len(fields) {
+			path = fields[i+2]
+			i += 2
+		}
+		j, ok := index[path]
+		if !ok {
+			continue
+		}
+		if parts[0] == "-" && parts[1] == "-" {
+			changes[j].Binary = true
+			continue
+		}
+		changes[j].Added, _ = strconv.Atoi(parts[0])
+		changes[j].Deleted, _ = strconv.Atoi(parts[1])
+	}
+	return changes, nil
+}
+
+// maxHistoryPaths bounds the pathspecs passed to `git log`; a change set
+// wider than this is not meaningfully "the same paths" anyway.
+const maxHistoryPaths = 100
+
+// RecentSubjects returns up to n recent non-merge commit subjects, newest
+// first, optionally limited to commits touching paths.
+func (c *CLI) RecentSubjects(
+	ctx context.Context,
+	n int,
+	paths []string,
+) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
 	}
-	return run(ctx, "diff", target+"...HEAD")
+	born, err := headExists(ctx)
+	if err != nil || !born {
+		return nil, err
+	}
+	args := []string{"log", "-n", strconv.Itoa(n), "--no-merges", "--format=%s"}
+	if len(paths) > 0 && len(paths) <= maxHistoryPaths {
+		args = append(args, "--")
+		for _, p := range paths {
+			args = append(args, ":(top,literal)"+p)
+		}
+	}
+	out, err := run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var subjects []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			subjects = append(subjects, line)
+		}
+	}
+	return subjects, nil
+}
+
+// CurrentBranch returns the short name of the checked-out branch, or ""
+// when HEAD is detached. It works before the first commit, too.
+func (c *CLI) CurrentBranch(ctx context.Context) (string, error) {
+	if _, err := run(ctx, "rev-parse", "--git-dir"); err != nil {
+		return "", err
+	}
+	out, err := run(ctx, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return "", nil
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Commit runs `git commit -F` on a temporary file holding message, so
+// quotes and multi-line messages reach git unmodified. Hooks run unless
+// flags.NoVerify is set; with flags.Edit git opens the configured editor
+// on the terminal.
+func (c *CLI) Commit(
+	ctx context.Context,
+	message string,
+	flags app.CommitFlags,
+) (string, error) {
+	f, err := os.CreateTemp("", "lazycommit-msg-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(message + "\n"); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	args := []string{"commit", "--quiet", "-F", f.Name()}
+	if flags.Edit {
+		args = append(args, "--edit")
+	}
+	if flags.NoVerify {
+		args = append(args, "--no-verify")
+	}
+	if flags.Sign {
+		args = append(args, "--gpg-sign")
+	}
+	if flags.SignOff {
+		args = append(args, "--signoff")
+	}
+	if flags.Edit {
+		err = runInteractive(ctx, args...)
+	} else {
+		_, err = run(ctx, args...)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	out, err := run(ctx, "rev-parse", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// CheckBranchName runs `git check-ref-format --branch`.
+func (c *CLI) CheckBranchName(ctx context.Context, name string) error {
+	if _, err := run(ctx, "check-ref-format", "--branch", name); err != nil {
+		return fmt.Errorf("%q is not a valid branch name", name)
+	}
+	return nil
+}
+
+// CreateBranch runs `git switch -c`, which carries staged and unstaged
+// changes over to the new branch.
+func (c *CLI) CreateBranch(ctx context.Context, name string) error {
+	_, err := run(ctx, "switch", "--quiet", "-c", name)
+	return err
 }
 
 // RepoRoot returns the repository top-level directory, or "" when the
@@ -41,7 +309,44 @@ func (c *CLI) RepoRoot(ctx context.Context) string {
 }
 
 func run(ctx context.Context, args ...string) (string, error) {
+	return runInput(ctx, "", nil, args...)
+}
+
+// headExists reports whether HEAD points at a commit; it is false on an
+// unborn branch. Other failures, such as not being in a repository, are
+// errors.
+func headExists(ctx context.Context) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "HEAD")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && stderr.Len() == 0:
+		// --quiet turns a missing ref into a bare exit status 1.
+		return false, nil
+	}
+	msg := strings.TrimSpace(stderr.String())
+	if msg == "" {
+		msg = err.Error()
+	}
+	return false, fmt.Errorf("git rev-parse --verify HEAD: %s", msg)
+}
+
+// runInput is run with stdin and extra environment variables.
+func runInput(
+	ctx context.Context,
+	stdin string,
+	env []string,
+	args ...string,
+) (string, error) {
 	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -54,3 +359,16 @@ func run(ctx context.Context, args ...string) (string, error) {
 	}
 	return stdout.String(), nil
 }
+
+// runInteractive runs git attached to the terminal, for commands that
+// start an editor.
+func runInteractive(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
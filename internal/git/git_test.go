@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+	"github.com/m7medvision/lazycommit/internal/domain"
 )
 
 // initRepo creates a throwaway git repository with one commit on main and
@@ -51,12 +54,12 @@ func TestStagedDiff(t *testing.T) {
 	cli := New()
 	ctx := context.Background()
 
-	out, err := cli.StagedDiff(ctx)
+	out, err := cli.StagedDiff(ctx, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if strings.TrimSpace(out) != "" {
-		t.Fatalf("expected empty staged diff, got %q", out)
+	if strings.TrimSpace(out.Patch) != "" {
+		t.Fatalf("expected empty staged diff, got %q", out.Patch)
 	}
 
 	if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte("staged content\n"), 0o644); err != nil {
@@ -64,12 +67,12 @@ func TestStagedDiff(t *testing.T) {
 	}
 	gitRun(t, dir, "add", "new.txt")
 
-	out, err = cli.StagedDiff(ctx)
+	out, err = cli.StagedDiff(ctx, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !strings.Contains(out, "staged content") {
-		t.Fatalf("staged diff missing content: %q", out)
+	if !strings.Contains(out.Patch, "staged content") {
+		t.Fatalf("staged diff missing content: %q", out.Patch)
 	}
 }
 
@@ -81,34 +84,211 @@ func TestBranchDiff(t *testing.T) {
 	gitRun(t, dir, "checkout", "-b", "feature")
 	writeAndCommit(t, dir, "feature.txt", "feature work\n", "feat: add feature")
 
-	out, err := cli.BranchDiff(ctx, "main")
+	out, err := cli.BranchDiff(ctx, "main", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !strings.Contains(out, "feature work") {
-		t.Fatalf("branch diff missing feature change: %q", out)
+	if !strings.Contains(out.Patch, "feature work") {
+		t.Fatalf("branch diff missing feature change: %q", out.Patch)
+	}
+}
+
+func TestBranchLog(t *testing.T) {
+	dir := initRepo(t)
+	gitRun(t, dir, "checkout", "-b", "feature")
+	writeAndCommit(t, dir, "a.txt", "a\n", "first")
+	writeAndCommit(t, dir, "b.txt", "b\n", "second")
+
+	subjects, err := New().BranchLog(context.Background(), "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(subjects, "|") != "first|second" {
+		t.Fatalf("subjects = %q", subjects)
+	}
+}
+
+func TestWorkingTreeDiffAndCreateBranch(t *testing.T) {
+	dir := initRepo(t)
+	cli := New()
+	ctx := context.Background()
+	if err := os.WriteFile(filepath.Join(dir, "base.txt"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := cli.WorkingTreeDiff(ctx, nil)
+	if err != nil || !strings.Contains(out.Patch, "+changed") {
+		t.Fatalf("working tree diff = %q, %v", out.Patch, err)
+	}
+	if err := cli.CheckBranchName(ctx, "feat/bad..name"); err == nil {
+		t.Fatal("expected an invalid name to be rejected")
+	}
+	if err := cli.CreateBranch(ctx, "feat/PAY-1-change-base"); err != nil {
+		t.Fatal(err)
+	}
+	if got := gitOutput(t, dir, "symbolic-ref", "--short", "HEAD"); got != "feat/PAY-1-change-base" {
+		t.Fatalf("HEAD = %q", got)
+	}
+	if got := gitOutput(t, dir, "diff", "--name-only"); got != "base.txt" {
+		t.Fatalf("local changes lost: %q", got)
 	}
 }
 
 func TestBranchDiffEmptyWhenNoDivergence(t *testing.T) {
 	initRepo(t)
-	out, err := New().BranchDiff(context.Background(), "main")
+	out, err := New().BranchDiff(context.Background(), "main", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if strings.TrimSpace(out) != "" {
-		t.Fatalf("expected empty diff on same commit, got %q", out)
+	if strings.TrimSpace(out.Patch) != "" {
+		t.Fatalf("expected empty diff on same commit, got %q", out.Patch)
 	}
 }
 
 func TestBranchDiffMissingBranch(t *testing.T) {
 	initRepo(t)
-	_, err := New().BranchDiff(context.Background(), "no-such-branch")
+	_, err := New().BranchDiff(context.Background(), "no-such-branch", nil)
 	if err == nil || !strings.Contains(err.Error(), "does not exist") {
 		t.Fatalf("expected missing-branch error, got %v", err)
 	}
 }
 
+func TestStagedDiffExcludesGlobs(t *testing.T) {
+	dir := initRepo(t)
+	if err := os.MkdirAll(filepath.Join(dir, "web", "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"main.go":                "package main\n",
+		"go.sum":                 "example.com/x v1 h1:abc\n",
+		"web/sub/pnpm-lock.yaml": "lockfileVersion: 9\n",
+		"web/sub/component.snap": "snapshot\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitRun(t, dir, "add", ".")
+	t.Chdir(filepath.Join(dir, "web"))
+
+	out, err := New().StagedDiff(context.Background(), []string{"**/go.sum", "**/pnpm-lock.yaml", "**/*.snap"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Patch, "package main") {
+		t.Fatalf("non-excluded file missing (pathspecs must be anchored at the top): %q", out.Patch)
+	}
+	for _, hidden := range []string{"h1:abc", "lockfileVersion", "snapshot"} {
+		if strings.Contains(out.Patch, hidden) {
+			t.Fatalf("excluded content %q leaked into patch", hidden)
+		}
+	}
+	want := "go.sum,web/sub/component.snap,web/sub/pnpm-lock.yaml"
+	if got := strings.Join(out.Excluded, ","); got != want {
+		t.Fatalf("excluded = %q, want %q", got, want)
+	}
+}
+
+func TestStagedDiffChangeSet(t *testing.T) {
+	dir := initRepo(t)
+	writeAndCommit(t, dir, "old.go", strings.Repeat("package old // stable content\n", 10), "add old")
+	writeAndCommit(t, dir, "gone.txt", "one\ntwo\n", "add gone")
+
+	gitRun(t, dir, "mv", "old.go", "new.go")
+	gitRun(t, dir, "rm", "-q", "gone.txt")
+	if err := os.WriteFile(filepath.Join(dir, "base.txt"), []byte("base\nmore\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "logo.bin"), []byte{0, 1, 2, 0, 255}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "base.txt", "logo.bin")
+
+	out, err := New().StagedDiff(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]domain.FileChange)
+	for _, c := range out.Changes {
+		got[c.Path] = c
+	}
+	if c := got["new.go"]; c.Status != domain.ChangeRenamed || c.OldPath != "old.go" {
+		t.Fatalf("rename not detected: %+v", c)
+	}
+	if c := got["gone.txt"]; c.Status != domain.ChangeDeleted || c.Deleted != 2 {
+		t.Fatalf("deletion wrong: %+v", c)
+	}
+	if c := got["base.txt"]; c.Status != domain.ChangeModified || c.Added != 1 || c.Deleted != 0 {
+		t.Fatalf("modification wrong: %+v", c)
+	}
+	if c := got["logo.bin"]; c.Status != domain.ChangeAdded || !c.Binary {
+		t.Fatalf("binary addition wrong: %+v", c)
+	}
+	if len(out.Changes) != 4 {
+		t.Fatalf("expected 4 changes, got %+v", out.Changes)
+	}
+}
+
+func TestRecentSubjects(t *testing.T) {
+	dir := initRepo(t)
+	writeAndCommit(t, dir, "billing.go", "package billing\n", "billing: add invoices")
+	writeAndCommit(t, dir, "other.go", "package other\n", "other: unrelated change")
+	cli := New()
+	ctx := context.Background()
+
+	all, err := cli.RecentSubjects(ctx, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(all, "|") != "other: unrelated change|billing: add invoices" {
+		t.Fatalf("unexpected subjects: %q", all)
+	}
+
+	scoped, err := cli.RecentSubjects(ctx, 5, []string{"billing.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(scoped, "|") != "billing: add invoices" {
+		t.Fatalf("path filter not applied: %q", scoped)
+	}
+}
+
+func TestRecentSubjectsWithoutCommits(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	gitRun(t, dir, "init", "-b", "main")
+	subjects, err := New().RecentSubjects(context.Background(), 5, nil)
+	if err != nil || len(subjects) != 0 {
+		t.Fatalf("expected no subjects and no error, got %q %v", subjects, err)
+	}
+}
+
+func TestRecentSubjectsOutsideRepository(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(t.TempDir()))
+	if _, err := New().RecentSubjects(context.Background(), 5, nil); err == nil {
+		t.Fatal("expected an error outside a repository")
+	}
+}
+
+func TestCurrentBranch(t *testing.T) {
+	dir := initRepo(t)
+	cli := New()
+	ctx := context.Background()
+
+	gitRun(t, dir, "checkout", "-q", "-b", "feature/PAY-1234-refund-flow")
+	branch, err := cli.CurrentBranch(ctx)
+	if err != nil || branch != "feature/PAY-1234-refund-flow" {
+		t.Fatalf("branch = %q, %v", branch, err)
+	}
+
+	gitRun(t, dir, "checkout", "-q", "--detach")
+	branch, err = cli.CurrentBranch(ctx)
+	if err != nil || branch != "" {
+		t.Fatalf("detached HEAD should yield no branch, got %q, %v", branch, err)
+	}
+}
+
 func TestRepoRoot(t *testing.T) {
 	dir := initRepo(t)
 	got := New().RepoRoot(context.Background())
@@ -127,3 +307,284 @@ func TestRepoRootOutsideRepo(t *testing.T) {
 		t.Logf("unexpected repo root %q (tolerated on unusual setups)", got)
 	}
 }
+
+func TestCommitKeepsMessageVerbatimAndHonorsNoVerify(t *testing.T) {
+	dir := initRepo(t)
+	cli := New()
+	ctx := context.Background()
+
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho rejected by hook >&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "a.txt")
+
+	msg := "fix: handle \"quoted\" $HOME and `ticks`\n\nSecond paragraph."
+	if _, err := cli.Commit(ctx, msg, app.CommitFlags{}); err == nil || !strings.Contains(err.Error(), "rejected by hook") {
+		t.Fatalf("expected the pre-commit hook to run and fail, got %v", err)
+	}
+	hash, err := cli.Commit(ctx, msg, app.CommitFlags{NoVerify: true, SignOff: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("git", "log", "-1", "--format=%h%n%B").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := hash + "\n" + msg + "\n\nSigned-off-by: test <test@example.com>"
+	if got := strings.TrimSpace(string(out)); got != want {
+		t.Fatalf("commit = %q, want %q", got, want)
+	}
+}
+
+func TestInstallHookChainsAndRestoresExistingHook(t *testing.T) {
+	dir := initRepo(t)
+	cli := New()
+	ctx := context.Background()
+	gitRun(t, dir, "config", "core.hooksPath", ".githooks")
+
+	hookDir := filepath.Join(dir, ".githooks")
+	if err := os.MkdirAll(hookDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	existing := "#!/bin/sh\necho \"$2\" > chained-ran\n"
+	hook := filepath.Join(hookDir, domain.PrepareCommitMsgHook)
+	if err := os.WriteFile(hook, []byte(existing), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := cli.InstallHook(ctx, domain.PrepareCommitMsgHook, domain.PrepareCommitMsgScript)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Path != hook || !status.Installed || !status.Chained {
+		t.Fatalf("status = %+v, want installed at %s with chain", status, hook)
+	}
+	if _, err := cli.InstallHook(ctx, domain.PrepareCommitMsgHook, domain.PrepareCommitMsgScript); err != nil {
+		t.Fatalf("reinstall must succeed: %v", err)
+	}
+
+	writeAndCommit(t, dir, "a.txt", "a\n", "add a")
+	ran, err := os.ReadFile(filepath.Join(dir, "chained-ran"))
+	if err != nil || strings.TrimSpace(string(ran)) != "message" {
+		t.Fatalf("chained hook did not run with git's arguments: %q, %v", ran, err)
+	}
+
+	if _, err := cli.UninstallHook(ctx, domain.PrepareCommitMsgHook); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := os.ReadFile(hook)
+	if err != nil || string(restored) != existing {
+		t.Fatalf("original hook not restored: %q, %v", restored, err)
+	}
+	if _, err := cli.UninstallHook(ctx, domain.PrepareCommitMsgHook); err == nil {
+		t.Fatal("uninstalling over a foreign hook must fail")
+	}
+}
+
+func TestCommentChar(t *testing.T) {
+	dir := initRepo(t)
+	cli := New()
+	for _, tc := range []struct{ set, want string }{
+		{"", "#"},
+		{";", ";"},
+		{"auto", "#"},
+	} {
+		if tc.set != "" {
+			gitRun(t, dir, "config", "core.commentChar", tc.set)
+		}
+		got, err := cli.CommentChar(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Fatalf("core.commentChar %q: got %q, want %q", tc.set, got, tc.want)
+		}
+	}
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git %v: %v", args, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestCommitDiff(t *testing.T) {
+	dir := initRepo(t)
+	cli := New()
+	writeAndCommit(t, dir, "b.txt", "b\n", "add b")
+
+	out, err := cli.CommitDiff(context.Background(), "HEAD", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Patch, "+++ b/b.txt") || strings.Contains(out.Patch, "base.txt") {
+		t.Fatalf("HEAD patch = %q", out.Patch)
+	}
+	root, err := cli.CommitDiff(context.Background(), "HEAD~1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(root.Changes) != 1 || root.Changes[0].Path != "base.txt" || root.Changes[0].Status != domain.ChangeAdded {
+		t.Fatalf("root commit changes = %+v", root.Changes)
+	}
+	if _, err := cli.CommitDiff(context.Background(), "nope", nil); err == nil {
+		t.Fatal("expected error for unknown commit")
+	}
+}
+
+func TestRewordHeadAndOlderCommits(t *testing.T) {
+	dir := initRepo(t)
+	cli := New()
+	ctx := context.Background()
+	writeAndCommit(t, dir, "a.txt", "a\n", "wip")
+	writeAndCommit(t, dir, "b.txt", "b\n", "more wip")
+	if err := os.WriteFile(filepath.Join(dir, "staged.txt"), []byte("s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "staged.txt")
+	treeBefore := gitOutput(t, dir, "rev-parse", "HEAD^{tree}")
+
+	if _, err := cli.Reword(ctx, "HEAD", "feat: add b"); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := cli.Reword(ctx, "HEAD~1", "feat: add a\n\nWith a body.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gitOutput(t, dir, "log", "--format=%s", "-3"); got != "feat: add b\nfeat: add a\ninitial commit" {
+		t.Fatalf("log = %q", got)
+	}
+	if got := gitOutput(t, dir, "log", "-1", "--format=%B", "HEAD~1"); got != "feat: add a\n\nWith a body." {
+		t.Fatalf("body = %q", got)
+	}
+	if got := gitOutput(t, dir, "rev-parse", "--short", "HEAD~1"); got != hash {
+		t.Fatalf("returned hash %s, HEAD~1 is %s", hash, got)
+	}
+	if got := gitOutput(t, dir, "rev-parse", "HEAD^{tree}"); got != treeBefore {
+		t.Fatal("rewording changed the tree")
+	}
+	if got := gitOutput(t, dir, "diff", "--cached", "--name-only"); got != "staged.txt" {
+		t.Fatalf("staged changes lost: %q", got)
+	}
+	if _, err := cli.Reword(ctx, "HEAD~2", "chore: initial commit"); err != nil {
+		t.Fatalf("root commit: %v", err)
+	}
+	if got := gitOutput(t, dir, "log", "--format=%s", "-3"); got != "feat: add b\nfeat: add a\nchore: initial commit" {
+		t.Fatalf("log after root reword = %q", got)
+	}
+}
+
+func TestRewordHeadKeepsHashLines(t *testing.T) {
+	dir := initRepo(t)
+	writeAndCommit(t, dir, "a.txt", "a\n", "wip")
+	if _, err := New().Reword(context.Background(), "HEAD", "fix: parse ids\n\n# is no longer a comment"); err != nil {
+		t.Fatal(err)
+	}
+	if got := gitOutput(t, dir, "log", "-1", "--format=%B"); got != "fix: parse ids\n\n# is no longer a comment" {
+		t.Fatalf("body = %q", got)
+	}
+}
+
+func TestRangeCommits(t *testing.T) {
+	dir := initRepo(t)
+	gitRun(t, dir, "tag", "v1.0.0")
+	writeAndCommit(t, dir, "a.txt", "a\n", "feat: add a")
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "b.txt")
+	gitRun(t, dir, "commit", "-m", "fix: add b", "-m", "BREAKING CHANGE: b moved")
+
+	commits, err := New().RangeCommits(context.Background(), "v1.0.0", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 || commits[0].Message != "feat: add a" || commits[1].Message != "fix: add b\n\nBREAKING CHANGE: b moved" {
+		t.Fatalf("commits = %+v", commits)
+	}
+	if _, err := New().RangeCommits(context.Background(), "v9", "HEAD"); err == nil {
+		t.Fatal("expected a missing revision to fail")
+	}
+}
+
+func TestReleaseTagsAndCreateTag(t *testing.T) {
+	dir := initRepo(t)
+	cli := New()
+	ctx := context.Background()
+	gitRun(t, dir, "tag", "v1.0.0")
+	gitRun(t, dir, "checkout", "-q", "-b", "other")
+	writeAndCommit(t, dir, "o.txt", "o\n", "elsewhere")
+	gitRun(t, dir, "tag", "v9.0.0")
+	gitRun(t, dir, "checkout", "-q", "main")
+
+	tags, err := cli.ReleaseTags(ctx)
+	if err != nil || strings.Join(tags, ",") != "v1.0.0" {
+		t.Fatalf("tags = %q, %v", tags, err)
+	}
+	if err := cli.CreateTag(ctx, "v1.1.0", "Release v1.1.0\n\n## [v1.1.0]\n\n### Added\n"); err != nil {
+		t.Fatal(err)
+	}
+	if got := gitOutput(t, dir, "tag", "-l", "--format=%(contents)", "v1.1.0"); got != "Release v1.1.0\n\n## [v1.1.0]\n\n### Added" {
+		t.Fatalf("tag message = %q", got)
+	}
+}
+
+func TestPublishedIn(t *testing.T) {
+	dir := initRepo(t)
+	cli := New()
+	gitRun(t, dir, "update-ref", "refs/remotes/origin/main", "HEAD")
+	writeAndCommit(t, dir, "a.txt", "a\n", "local only")
+
+	if remote, err := cli.PublishedIn(context.Background(), "HEAD"); err != nil || remote != "" {
+		t.Fatalf("HEAD: %q, %v", remote, err)
+	}
+	if remote, err := cli.PublishedIn(context.Background(), "HEAD~1"); err != nil || remote != "origin/main" {
+		t.Fatalf("HEAD~1: %q, %v", remote, err)
+	}
+}
+
+func TestBranchCommitsAndRewriteMessages(t *testing.T) {
+	dir := initRepo(t)
+	cli := New()
+	ctx := context.Background()
+	gitRun(t, dir, "checkout", "-q", "-b", "topic")
+	writeAndCommit(t, dir, "a.txt", "a\n", "wip")
+	gitRun(t, dir, "checkout", "-q", "main")
+	writeAndCommit(t, dir, "m.txt", "m\n", "on main")
+	gitRun(t, dir, "checkout", "-q", "topic")
+	gitRun(t, dir, "merge", "-q", "--no-edit", "main")
+	writeAndCommit(t, dir, "b.txt", "b\n", "wip again")
+	oldHead := gitOutput(t, dir, "rev-parse", "HEAD")
+
+	head, commits, err := cli.BranchCommits(ctx, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != oldHead || len(commits) != 3 || commits[0].Subject != "wip" || !commits[1].Merge || commits[2].Merge {
+		t.Fatalf("head %s, commits %+v", head, commits)
+	}
+
+	messages := map[string]string{commits[0].Hash: "feat: add a", commits[2].Hash: "feat: add b"}
+	if _, err := cli.RewriteMessages(ctx, "main", "0000000", messages); err == nil {
+		t.Fatal("expected a stale head to be refused")
+	}
+	backup, err := cli.RewriteMessages(ctx, "main", head, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gitOutput(t, dir, "log", "--first-parent", "--format=%s", "-3"); got != "feat: add b\nMerge branch 'main' into topic\nfeat: add a" {
+		t.Fatalf("log = %q", got)
+	}
+	if backup != "refs/lazycommit/backup/topic" || gitOutput(t, dir, "rev-parse", backup) != oldHead {
+		t.Fatalf("backup %q does not point at the old head", backup)
+	}
+}
@@ -0,0 +1,354 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+)
+
+// CommitDiff returns the changes rev introduced, relative to its first
+// parent or, for a root commit, to the empty tree: the patch `git show`
+// prints for it.
+func (c *CLI) CommitDiff(
+	ctx context.Context,
+	rev string,
+	exclude []string,
+) (app.RawDiff, error) {
+	sha, err := resolveCommit(ctx, rev)
+	if err != nil {
+		return app.RawDiff{}, err
+	}
+	parent, ok := firstParent(ctx, sha)
+	if !ok {
+		if parent, err = emptyTree(ctx); err != nil {
+			return app.RawDiff{}, err
+		}
+	}
+	return diff(ctx, []string{parent, sha}, exclude)
+}
+
+// RangeCommits returns the non-merge commits in from..to, oldest first,
+// with their full messages. An empty from lists all of to's history.
+func (c *CLI) RangeCommits(
+	ctx context.Context,
+	from, to string,
+) ([]app.LoggedCommit, error) {
+	toSHA, err := resolveCommit(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	spec := toSHA
+	if from != "" {
+		fromSHA, err := resolveCommit(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		spec = fromSHA + ".." + toSHA
+	}
+	out, err := run(ctx, "log", "--reverse", "--no-merges",
+		"--format=%H%x00%h%x00%B%x1e", spec)
+	if err != nil {
+		return nil, err
+	}
+	var commits []app.LoggedCommit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, app.LoggedCommit{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Message:   strings.TrimSpace(fields[2]),
+		})
+	}
+	return commits, nil
+}
+
+// ReleaseTags lists the tags reachable from HEAD.
+func (c *CLI) ReleaseTags(ctx context.Context) ([]string, error) {
+	out, err := run(ctx, "tag", "--list", "--merged", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// CreateTag creates an annotated tag at HEAD. The message is kept verbatim
+// so markdown headings are not taken for comments.
+func (c *CLI) CreateTag(ctx context.Context, name, message string) error {
+	_, err := runInput(ctx, strings.TrimRight(message, "\n")+"\n", nil,
+		"tag", "-a", "--cleanup=verbatim", "-F", "-", name)
+	return err
+}
+
+// PublishedIn returns a remote-tracking branch that already contains rev,
+// or "" when rev has not been pushed anywhere lazycommit can see.
+func (c *CLI) PublishedIn(ctx context.Context, rev string) (string, error) {
+	sha, err := resolveCommit(ctx, rev)
+	if err != nil {
+		return "", err
+	}
+	out, err := run(ctx, "for-each-ref", "--contains", sha,
+		"--format=%(refname:short)", "refs/remotes")
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range strings.Split(out, "\n") {
+		ref = strings.TrimSpace(ref)
+		if ref != "" && !strings.HasSuffix(ref, "/HEAD") {
+			return ref, nil
+		}
+	}
+	return "", nil
+}
+
+// Reword replaces rev's message and returns the rewritten commit's
+// abbreviated hash. HEAD is amended; an older commit on the current branch
+// is rewritten by replaying it and its descendants with their original
+// trees, so nothing can conflict and the index and working tree are left
+// untouched. Hooks do not run, and message is kept verbatim on both paths.
+func (c *CLI) Reword(ctx context.Context, rev, message string) (string, error) {
+	sha, err := resolveCommit(ctx, rev)
+	if err != nil {
+		return "", err
+	}
+	head, err := resolveCommit(ctx, "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	if sha == head {
+		if _, err := runInput(ctx, message+"\n", nil,
+			"commit", "--amend", "--only", "--allow-empty", "--no-verify",
+			"--quiet", "--cleanup=verbatim", "-F", "-"); err != nil {
+			return "", err
+		}
+		return shortHash(ctx, "HEAD")
+	}
+
+	ref, err := branchRef(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, err := run(ctx, "merge-base", "--is-ancestor", sha, head); err != nil {
+		return "", fmt.Errorf("commit %s is not on the current branch", rev)
+	}
+	base, _ := firstParent(ctx, sha)
+	rewritten, err := replay(ctx, ref, head, base,
+		map[string]string{sha: message})
+	if err != nil {
+		return "", err
+	}
+	return shortHash(ctx, rewritten[sha])
+}
+
+// BackupRefPrefix namespaces the refs RewriteMessages leaves behind.
+const BackupRefPrefix = "refs/lazycommit/backup/"
+
+// BranchCommits returns HEAD's full hash and the commits in base..HEAD,
+// oldest first, in the order replay visits them.
+func (c *CLI) BranchCommits(
+	ctx context.Context,
+	base string,
+) (string, []app.BranchCommit, error) {
+	if _, err := branchRef(ctx); err != nil {
+		return "", nil, err
+	}
+	baseSHA, err := resolveCommit(ctx, base)
+	if err != nil {
+		return "", nil, err
+	}
+	head, err := resolveCommit(ctx, "HEAD")
+	if err != nil {
+		return "", nil, err
+	}
+	out, err := run(ctx, "log", "--reverse", "--topo-order",
+		"--format=%H%x00%h%x00%P%x00%s", baseSHA+".."+head)
+	if err != nil {
+		return "", nil, err
+	}
+	var commits []app.BranchCommit
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		commits = append(commits, app.BranchCommit{
+			Hash:      fields[0],
+			ShortHash: fields[1],
+			Subject:   fields[3],
+			Merge:     len(strings.Fields(fields[2])) > 1,
+		})
+	}
+	return head, commits, nil
+}
+
+// RewriteMessages saves head under BackupRefPrefix plus the branch name,
+// then replays base..head with the new messages. The branch update fails
+// if the branch no longer points at head.
+func (c *CLI) RewriteMessages(
+	ctx context.Context,
+	base, head string,
+	messages map[string]string,
+) (string, error) {
+	ref, err := branchRef(ctx)
+	if err != nil {
+		return "", err
+	}
+	baseSHA, err := resolveCommit(ctx, base)
+	if err != nil {
+		return "", err
+	}
+	backup := BackupRefPrefix + strings.TrimPrefix(ref, "refs/heads/")
+	if _, err := run(ctx, "update-ref",
+		"-m", "lazycommit: backup before rewrite", backup, head); err != nil {
+		return "", err
+	}
+	if _, err := replay(ctx, ref, head, baseSHA, messages); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
+// replay recreates the commits in base..oldHead (all of oldHead's history
+// when base is empty), oldest first. A commit is recreated, with its tree,
+// author, and message, only when it gets a new message from messages or
+// one of its parents was recreated; the rest keep their hashes. The branch
+// ref then moves in one compare-and-swap update. It returns old to new
+// hashes for the recreated commits.
+func replay(
+	ctx context.Context,
+	ref, oldHead, base string,
+	messages map[string]string,
+) (map[string]string, error) {
+	spec := oldHead
+	if base != "" {
+		spec = base + ".." + oldHead
+	}
+	out, err := run(ctx, "rev-list", "--reverse", "--topo-order",
+		"--parents", spec)
+	if err != nil {
+		return nil, err
+	}
+
+	rewritten := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		sha, parents := fields[0], fields[1:]
+		message, reworded := messages[sha]
+		changed := reworded
+		for i, p := range parents {
+			if n, ok := rewritten[p]; ok {
+				parents[i] = n
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if !reworded {
+			message, err = run(ctx, "log", "-1", "--format=%B", sha)
+			if err != nil {
+				return nil, err
+			}
+		}
+		n, err := recommit(ctx, sha, parents, message)
+		if err != nil {
+			return nil, err
+		}
+		rewritten[sha] = n
+	}
+
+	newHead, ok := rewritten[oldHead]
+	if !ok {
+		return rewritten, nil
+	}
+	if _, err := run(ctx, "update-ref", "-m", "lazycommit: rewrite messages",
+		ref, newHead, oldHead); err != nil {
+		return nil, err
+	}
+	return rewritten, nil
+}
+
+// recommit creates a copy of sha with new parents and message, keeping
+// its tree and author; the committer is the current user, as with rebase.
+func recommit(
+	ctx context.Context,
+	sha string,
+	parents []string,
+	message string,
+) (string, error) {
+	meta, err := run(ctx, "log", "-1", "--date=raw",
+		"--format=%T%x00%an%x00%ae%x00%ad", sha)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Split(strings.TrimSpace(meta), "\x00")
+	if len(fields) != 4 {
+		return "", fmt.Errorf("unexpected metadata for %s: %q", sha, meta)
+	}
+	env := []string{
+		"GIT_AUTHOR_NAME=" + fields[1],
+		"GIT_AUTHOR_EMAIL=" + fields[2],
+		"GIT_AUTHOR_DATE=" + fields[3],
+	}
+	args := []string{"commit-tree", fields[0]}
+	for _, p := range parents {
+		args = append(args, "-p", p)
+	}
+	out, err := runInput(ctx, strings.TrimRight(message, "\n")+"\n", env, args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// firstParent returns sha's first parent; ok is false for a root commit.
+func firstParent(ctx context.Context, sha string) (string, bool) {
+	out, err := run(ctx, "rev-parse", "--verify", "--quiet", sha+"^")
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(out), true
+}
+
+// emptyTree returns the id of the empty tree, which differs between SHA-1
+// and SHA-256 repositories.
+func emptyTree(ctx context.Context) (string, error) {
+	out, err := runInput(ctx, "", nil, "hash-object", "-t", "tree", "--stdin")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func resolveCommit(ctx context.Context, rev string) (string, error) {
+	out, err := run(ctx, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("commit %q does not exist", rev)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func shortHash(ctx context.Context, rev string) (string, error) {
+	out, err := run(ctx, "rev-parse", "--short", rev)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// branchRef returns the full ref of the checked-out branch.
+func branchRef(ctx context.Context) (string, error) {
+	out, err := run(ctx, "symbolic-ref", "--quiet", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("HEAD is detached; check out a branch first")
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,122 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/m7medvision/lazycommit/internal/app"
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// HookStatus inspects the hook git would run for name.
+func (c *CLI) HookStatus(ctx context.Context, name string) (app.HookStatus, error) {
+	out, err := run(ctx, "rev-parse", "--git-path", "hooks/"+name)
+	if err != nil {
+		return app.HookStatus{}, err
+	}
+	path, err := filepath.Abs(strings.TrimSpace(out))
+	if err != nil {
+		return app.HookStatus{}, err
+	}
+
+	status := app.HookStatus{Path: path}
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return app.HookStatus{}, err
+	case strings.Contains(string(content), domain.HookMarker):
+		status.Installed = true
+	default:
+		status.Foreign = true
+	}
+	if _, err := os.Stat(path + domain.ChainedHookSuffix); err == nil {
+		status.Chained = true
+	}
+	return status, nil
+}
+
+// CommentChar returns core.commentChar, or domain.DefaultCommentChar when
+// it is unset. "auto" means the default too: git picks a character that
+// no line of the message so far starts with, and a message being drafted
+// by the hook has no lines yet.
+func (c *CLI) CommentChar(ctx context.Context) (string, error) {
+	out, err := run(ctx, "config", "--get",
+		"--default", domain.DefaultCommentChar, "core.commentChar")
+	if err != nil {
+		return "", err
+	}
+	char := strings.TrimRight(out, "\n")
+	if char == "" || char == "auto" {
+		return domain.DefaultCommentChar, nil
+	}
+	return char, nil
+}
+
+// InstallHook writes script as the hook, creating the hooks directory if
+// core.hooksPath points at one that does not exist yet. Reinstalling
+// replaces lazycommit's own script in place.
+func (c *CLI) InstallHook(
+	ctx context.Context,
+	name, script string,
+) (app.HookStatus, error) {
+	status, err := c.HookStatus(ctx, name)
+	if err != nil {
+		return app.HookStatus{}, err
+	}
+	if status.Foreign {
+		chained := status.Path + domain.ChainedHookSuffix
+		if status.Chained {
+			return app.HookStatus{}, fmt.Errorf(
+				"%s already exists; move it away first", chained)
+		}
+		if err := os.Rename(status.Path, chained); err != nil {
+			return app.HookStatus{}, err
+		}
+		status.Chained = true
+	}
+	if err := os.MkdirAll(filepath.Dir(status.Path), 0o755); err != nil {
+		return app.HookStatus{}, err
+	}
+	if err := os.WriteFile(status.Path, []byte(script), 0o755); err != nil {
+		return app.HookStatus{}, err
+	}
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(status.Path, 0o755); err != nil {
+		return app.HookStatus{}, err
+	}
+	status.Installed, status.Foreign = true, false
+	return status, nil
+}
+
+// UninstallHook removes lazycommit's script and puts a chained hook back.
+func (c *CLI) UninstallHook(ctx context.Context, name string) (app.HookStatus, error) {
+	status, err := c.HookStatus(ctx, name)
+	if err != nil {
+		return app.HookStatus{}, err
+	}
+	switch {
+	case status.Foreign:
+		return app.HookStatus{}, fmt.Errorf(
+			"%s was not installed by lazycommit", status.Path)
+	case !status.Installed:
+		return app.HookStatus{}, fmt.Errorf("no %s hook is installed", name)
+	}
+	if err := os.Remove(status.Path); err != nil {
+		return app.HookStatus{}, err
+	}
+	status.Installed = false
+	if status.Chained {
+		chained := status.Path + domain.ChainedHookSuffix
+		if err := os.Rename(chained, status.Path); err != nil {
+			return app.HookStatus{}, err
+		}
+		status.Chained, status.Foreign = false, true
+	}
+	return status, nil
+}
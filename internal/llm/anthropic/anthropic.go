@@ -0,0 +1,145 @@
+// Package anthropic adapts the Anthropic Messages API to the app.Generator
+// port. It speaks plain HTTP so any endpoint with the same request shape
+// (the official API or a gateway in front of it) works.
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+const (
+	// DefaultBaseURL is the official API; the /v1/messages path is appended
+	// to it and to any configured base URL.
+	DefaultBaseURL = "https://api.anthropic.com"
+	// APIVersion is sent as the anthropic-version header.
+	APIVersion = "2023-06-01"
+	// maxTokens bounds each reply; it comfortably fits a full pull request
+	// description.
+	maxTokens = 4096
+)
+
+type Config struct {
+	// BaseURL is optional; DefaultBaseURL is used when empty.
+	BaseURL string
+	APIKey  string
+	Model   string
+}
+
+type Client struct {
+	http    *http.Client
+	baseURL string
+	apiKey  string
+	model   domain.ModelID
+}
+
+func New(cfg Config) (*Client, error) {
+	model, err := domain.NewModelID(cfg.Model)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic backend: %w", err)
+	}
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		http:    http.DefaultClient,
+		baseURL: baseURL,
+		apiKey:  cfg.APIKey,
+		model:   model,
+	}, nil
+}
+
+type messagesRequest struct {
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	System    string    `json:"system,omitempty"`
+	Messages  []message `json:"messages"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type messagesResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *Client) Generate(ctx context.Context, prompt domain.Prompt) (string, error) {
+	body, err := json.Marshal(messagesRequest{
+		Model:     c.model.String(),
+		MaxTokens: maxTokens,
+		System:    prompt.System,
+		Messages:  []message{{Role: "user", Content: prompt.User}},
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.baseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("messages request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("anthropic-version", APIVersion)
+	if c.apiKey != "" {
+		req.Header.Set("x-api-key", c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("messages request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("messages request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("messages request: %s: %s", resp.Status, errorMessage(raw))
+	}
+
+	var out messagesResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return "", fmt.Errorf("messages response: %w", err)
+	}
+	var text strings.Builder
+	for _, block := range out.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", errors.New("messages response had no text content")
+	}
+	return text.String(), nil
+}
+
+// errorMessage extracts the API's error message, falling back to the raw
+// body for proxies that answer in their own format.
+func errorMessage(raw []byte) string {
+	var e errorResponse
+	if json.Unmarshal(raw, &e) == nil && e.Error.Message != "" {
+		return e.Error.Message
+	}
+	return strings.TrimSpace(string(raw))
+}
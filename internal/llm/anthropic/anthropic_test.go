@@ -0,0 +1,94 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+func TestNewRequiresModel(t *testing.T) {
+	if _, err := New(Config{Model: "  "}); err == nil {
+		t.Fatal("expected error for blank model")
+	}
+}
+
+func TestGenerateSpeaksMessagesAPI(t *testing.T) {
+	var got messagesRequest
+	var gotKey, gotVersion, gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("x-api-key")
+		gotVersion = r.Header.Get("anthropic-version")
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"feat: one\n"},{"type":"text","text":"fix: two"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL + "/", APIKey: "test-key", Model: "test-model"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := client.Generate(context.Background(), domain.Prompt{System: "sys", User: "user"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "feat: one\nfix: two" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	if gotPath != "/v1/messages" {
+		t.Fatalf("unexpected path: %q", gotPath)
+	}
+	if gotKey != "test-key" || gotVersion != APIVersion {
+		t.Fatalf("unexpected headers: x-api-key=%q anthropic-version=%q", gotKey, gotVersion)
+	}
+	if got.Model != "test-model" || got.MaxTokens <= 0 {
+		t.Fatalf("model = %q, max_tokens = %d", got.Model, got.MaxTokens)
+	}
+	if got.System != "sys" {
+		t.Fatalf("system = %q", got.System)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Role != "user" || got.Messages[0].Content != "user" {
+		t.Fatalf("unexpected messages: %+v", got.Messages)
+	}
+}
+
+func TestGenerateNoText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content":[]}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, Model: "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Generate(context.Background(), domain.Prompt{}); err == nil {
+		t.Fatal("expected error for empty content")
+	}
+}
+
+func TestGenerateHTTPErrorSurfaces(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, Model: "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.Generate(context.Background(), domain.Prompt{})
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "invalid x-api-key") {
+		t.Fatalf("expected 401 with the API message, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Package command adapts any local program to the app.Generator port: the
+// prompt goes to the program's stdin and its stdout is the completion. It
+// suits wrappers such as the llm CLI, custom scripts, and air-gapped
+// inference binaries. Timeouts come from the context, which kills the
+// program when it is done.
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// ModelPlaceholder in any argument is replaced by the configured model, so
+// fallback models can select different models of the same program.
+const ModelPlaceholder = "{model}"
+
+// InputFormat selects how the prompt is written to the program's stdin.
+type InputFormat string
+
+const (
+	// InputJSON writes {"model": ..., "system": ..., "user": ...}.
+	InputJSON InputFormat = "json"
+	// InputText writes the system message, a blank line, and the user
+	// message.
+	InputText InputFormat = "text"
+)
+
+// waitDelay bounds how long a killed program's children may keep its
+// output pipes open.
+const waitDelay = 2 * time.Second
+
+type Config struct {
+	// Command is the program and its arguments; it is run directly, not
+	// through a shell.
+	Command []string
+	// Model is optional; it is substituted for ModelPlaceholder and sent in
+	// JSON input.
+	Model string
+	// InputFormat is optional; InputJSON is used when empty.
+	InputFormat InputFormat
+}
+
+type Client struct {
+	args   []string
+	model  string
+	format InputFormat
+}
+
+func New(cfg Config) (*Client, error) {
+	if len(cfg.Command) == 0 || strings.TrimSpace(cfg.Command[0]) == "" {
+		return nil, errors.New("exec backend: command is empty")
+	}
+	format := cfg.InputFormat
+	switch format {
+	case "":
+		format = InputJSON
+	case InputJSON, InputText:
+	default:
+		return nil, fmt.Errorf(
+			"exec backend: unknown input format %q (want %s or %s)",
+			format, InputJSON, InputText)
+	}
+	args := make([]string, len(cfg.Command))
+	for i, arg := range cfg.Command {
+		args[i] = strings.ReplaceAll(arg, ModelPlaceholder, cfg.Model)
+	}
+	return &Client{args: args, model: cfg.Model, format: format}, nil
+}
+
+type jsonInput struct {
+	Model  string `json:"model,omitempty"`
+	System string `json:"system"`
+	User   string `json:"user"`
+}
+
+func (c *Client) Generate(ctx context.Context, prompt domain.Prompt) (string, error) {
+	input, err := c.input(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, c.args[0], c.args[1:]...)
+	cmd.WaitDelay = waitDelay
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("running %s: %w", c.args[0], ctx.Err())
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				msg = "no output on stderr"
+			}
+			return "", fmt.Errorf("%s exited with status %d: %s",
+				c.args[0], exitErr.ExitCode(), msg)
+		}
+		return "", fmt.Errorf("running %s: %w", c.args[0], err)
+	}
+
+	out := strings.TrimSpace(stdout.String())
+	if out == "" {
+		return "", fmt.Errorf("%s printed nothing on stdout", c.args[0])
+	}
+	return out, nil
+}
+
+func (c *Client) input(prompt domain.Prompt) ([]byte, error) {
+	if c.format == InputText {
+		return []byte(prompt.System + "\n\n" + prompt.User + "\n"), nil
+	}
+	return json.Marshal(jsonInput{
+		Model:  c.model,
+		System: prompt.System,
+		User:   prompt.User,
+	})
+}
@@ -0,0 +1,106 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+func TestNewValidatesConfig(t *testing.T) {
+	if _, err := New(Config{}); err == nil {
+		t.Fatal("expected error for empty command")
+	}
+	if _, err := New(Config{Command: []string{"cat"}, InputFormat: "yaml"}); err == nil {
+		t.Fatal("expected error for unknown input format")
+	}
+}
+
+func TestGenerateWritesJSONPrompt(t *testing.T) {
+	client, err := New(Config{Command: []string{"cat"}, Model: "local-7b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := client.Generate(context.Background(), domain.Prompt{System: "sys", User: "user\nline"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got jsonInput
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("stdin was not JSON: %q", out)
+	}
+	if got != (jsonInput{Model: "local-7b", System: "sys", User: "user\nline"}) {
+		t.Fatalf("unexpected input: %+v", got)
+	}
+}
+
+func TestGenerateWritesTextPrompt(t *testing.T) {
+	client, err := New(Config{Command: []string{"cat"}, InputFormat: InputText})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := client.Generate(context.Background(), domain.Prompt{System: "sys", User: "user"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "sys\n\nuser" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func TestGenerateSubstitutesModel(t *testing.T) {
+	client, err := New(Config{Command: []string{"echo", "-m", "{model}", "--model={model}"}, Model: "mistral"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := client.Generate(context.Background(), domain.Prompt{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "-m mistral --model=mistral" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+}
+
+func TestGenerateNonZeroExitCarriesStderr(t *testing.T) {
+	client, err := New(Config{Command: []string{"sh", "-c", "echo partial; echo 'model not loaded' >&2; exit 3"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.Generate(context.Background(), domain.Prompt{})
+	if err == nil || !strings.Contains(err.Error(), "status 3") || !strings.Contains(err.Error(), "model not loaded") {
+		t.Fatalf("expected exit status and stderr in error, got %v", err)
+	}
+}
+
+func TestGenerateEmptyOutput(t *testing.T) {
+	client, err := New(Config{Command: []string{"true"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Generate(context.Background(), domain.Prompt{}); err == nil {
+		t.Fatal("expected error for empty stdout")
+	}
+}
+
+func TestGenerateStopsAtDeadline(t *testing.T) {
+	client, err := New(Config{Command: []string{"sleep", "10"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.Generate(ctx, domain.Prompt{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("program was not killed at the deadline: took %s", elapsed)
+	}
+}
@@ -0,0 +1,239 @@
+// Package gemini adapts Google's Gemini generateContent REST API to the
+// app.Generator port. It speaks plain HTTP so a proxy exposing the same
+// request shape works as well as the public endpoint.
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// DefaultBaseURL is the public API including its version; the
+// /models/<model>:generateContent path is appended to it and to any
+// configured base URL.
+const DefaultBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+type Config struct {
+	// BaseURL is optional; DefaultBaseURL is used when empty.
+	BaseURL string
+	APIKey  string
+	// APIKeyInQuery sends the key as the key query parameter instead of the
+	// x-goog-api-key header, for proxies that only accept it there.
+	APIKeyInQuery bool
+	Model         string
+}
+
+// BlockedError reports a reply withheld by Gemini's safety or content
+// filters, either because the prompt itself was blocked or because the
+// candidate answer was.
+type BlockedError struct {
+	// Prompt is true when the prompt was blocked and no answer was made.
+	Prompt bool
+	// Reason is the API's blockReason or finishReason, e.g. "SAFETY".
+	Reason string
+	// Categories are the harm categories that were rated blocked.
+	Categories []string
+}
+
+func (e *BlockedError) Error() string {
+	what := "reply"
+	if e.Prompt {
+		what = "prompt"
+	}
+	msg := fmt.Sprintf("gemini blocked the %s (%s)", what, e.Reason)
+	if len(e.Categories) > 0 {
+		msg += ": " + strings.Join(e.Categories, ", ")
+	}
+	return msg
+}
+
+// blockingFinishReasons are the finish reasons that mean the candidate was
+// withheld rather than finished or truncated.
+var blockingFinishReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+}
+
+type Client struct {
+	http          *http.Client
+	baseURL       string
+	apiKey        string
+	apiKeyInQuery bool
+	model         domain.ModelID
+}
+
+func New(cfg Config) (*Client, error) {
+	name := strings.TrimPrefix(strings.TrimSpace(cfg.Model), "models/")
+	model, err := domain.NewModelID(name)
+	if err != nil {
+		return nil, fmt.Errorf("gemini backend: %w", err)
+	}
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		http:          http.DefaultClient,
+		baseURL:       baseURL,
+		apiKey:        cfg.APIKey,
+		apiKeyInQuery: cfg.APIKeyInQuery,
+		model:         model,
+	}, nil
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+type part struct {
+	Text    string `json:"text"`
+	Thought bool   `json:"thought,omitempty"`
+}
+
+type generateRequest struct {
+	SystemInstruction *content  `json:"systemInstruction,omitempty"`
+	Contents          []content `json:"contents"`
+}
+
+type safetyRating struct {
+	Category string `json:"category"`
+	Blocked  bool   `json:"blocked"`
+}
+
+type generateResponse struct {
+	Candidates []struct {
+		Content       content        `json:"content"`
+		FinishReason  string         `json:"finishReason"`
+		SafetyRatings []safetyRating `json:"safetyRatings"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason   string         `json:"blockReason"`
+		SafetyRatings []safetyRating `json:"safetyRatings"`
+	} `json:"promptFeedback"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *Client) Generate(ctx context.Context, prompt domain.Prompt) (string, error) {
+	req := generateRequest{
+		Contents: []content{{Role: "user", Parts: []part{{Text: prompt.User}}}},
+	}
+	if prompt.System != "" {
+		req.SystemInstruction = &content{Parts: []part{{Text: prompt.System}}}
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.endpoint(), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("generateContent request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" && !c.apiKeyInQuery {
+		httpReq.Header.Set("x-goog-api-key", c.apiKey)
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("generateContent request: %w",
+			redactKey(err, c.apiKey))
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("generateContent request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("generateContent request: %s: %s",
+			resp.Status, errorMessage(raw))
+	}
+
+	var out generateResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return "", fmt.Errorf("generateContent response: %w", err)
+	}
+	if reason := out.PromptFeedback.BlockReason; reason != "" {
+		return "", &BlockedError{
+			Prompt:     true,
+			Reason:     reason,
+			Categories: blockedCategories(out.PromptFeedback.SafetyRatings),
+		}
+	}
+	if len(out.Candidates) == 0 {
+		return "", errors.New("generateContent returned no candidates")
+	}
+	candidate := out.Candidates[0]
+	if blockingFinishReasons[candidate.FinishReason] {
+		return "", &BlockedError{
+			Reason:     candidate.FinishReason,
+			Categories: blockedCategories(candidate.SafetyRatings),
+		}
+	}
+	var text strings.Builder
+	for _, p := range candidate.Content.Parts {
+		if !p.Thought {
+			text.WriteString(p.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", errors.New("generateContent candidate had no text")
+	}
+	return text.String(), nil
+}
+
+func (c *Client) endpoint() string {
+	u := c.baseURL + "/models/" + url.PathEscape(c.model.String()) +
+		":generateContent"
+	if c.apiKey != "" && c.apiKeyInQuery {
+		u += "?" + url.Values{"key": {c.apiKey}}.Encode()
+	}
+	return u
+}
+
+func blockedCategories(ratings []safetyRating) []string {
+	var out []string
+	for _, r := range ratings {
+		if r.Blocked {
+			out = append(out, r.Category)
+		}
+	}
+	return out
+}
+
+// redactKey keeps a query-parameter key out of transport errors, which
+// quote the full request URL.
+func redactKey(err error, key string) error {
+	var urlErr *url.Error
+	if key != "" && errors.As(err, &urlErr) {
+		urlErr.URL = strings.ReplaceAll(urlErr.URL, url.QueryEscape(key), "REDACTED")
+	}
+	return err
+}
+
+func errorMessage(raw []byte) string {
+	var e errorResponse
+	if json.Unmarshal(raw, &e) == nil && e.Error.Message != "" {
+		return e.Error.Message
+	}
+	return strings.TrimSpace(string(raw))
+}
@@ -0,0 +1,153 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+func TestNewRequiresModel(t *testing.T) {
+	if _, err := New(Config{Model: "  "}); err == nil {
+		t.Fatal("expected error for blank model")
+	}
+}
+
+func TestGenerateSpeaksGenerateContent(t *testing.T) {
+	var got generateRequest
+	var gotKey, gotPath, gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("x-goog-api-key")
+		gotPath = r.URL.Path
+		gotQuery = r.URL.RawQuery
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[` +
+			`{"text":"thinking...","thought":true},{"text":"feat: one\n"},{"text":"fix: two"}]},"finishReason":"STOP"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL + "/v1beta/", APIKey: "test-key", Model: "models/gemini-2.0-flash"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := client.Generate(context.Background(), domain.Prompt{System: "sys", User: "user"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "feat: one\nfix: two" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	if gotPath != "/v1beta/models/gemini-2.0-flash:generateContent" {
+		t.Fatalf("unexpected path: %q", gotPath)
+	}
+	if gotKey != "test-key" || gotQuery != "" {
+		t.Fatalf("key should travel in the header only: header=%q query=%q", gotKey, gotQuery)
+	}
+	if got.SystemInstruction == nil || !reflect.DeepEqual(got.SystemInstruction.Parts, []part{{Text: "sys"}}) {
+		t.Fatalf("unexpected system instruction: %+v", got.SystemInstruction)
+	}
+	if len(got.Contents) != 1 || got.Contents[0].Role != "user" || !reflect.DeepEqual(got.Contents[0].Parts, []part{{Text: "user"}}) {
+		t.Fatalf("unexpected contents: %+v", got.Contents)
+	}
+}
+
+func TestGenerateAPIKeyInQuery(t *testing.T) {
+	var gotKey, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.URL.Query().Get("key")
+		gotHeader = r.Header.Get("x-goog-api-key")
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, APIKey: "k&y", APIKeyInQuery: true, Model: "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Generate(context.Background(), domain.Prompt{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotKey != "k&y" || gotHeader != "" {
+		t.Fatalf("key should travel in the query only: query=%q header=%q", gotKey, gotHeader)
+	}
+}
+
+func TestGenerateBlockedPrompt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"promptFeedback":{"blockReason":"SAFETY","safetyRatings":[` +
+			`{"category":"HARM_CATEGORY_HARASSMENT","probability":"NEGLIGIBLE"},` +
+			`{"category":"HARM_CATEGORY_DANGEROUS_CONTENT","probability":"HIGH","blocked":true}]}}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, Model: "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.Generate(context.Background(), domain.Prompt{})
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) {
+		t.Fatalf("expected BlockedError, got %v", err)
+	}
+	want := &BlockedError{Prompt: true, Reason: "SAFETY", Categories: []string{"HARM_CATEGORY_DANGEROUS_CONTENT"}}
+	if !reflect.DeepEqual(blocked, want) {
+		t.Fatalf("got %+v, want %+v", blocked, want)
+	}
+}
+
+func TestGenerateBlockedReply(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"candidates":[{"finishReason":"RECITATION","safetyRatings":[]}]}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, Model: "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.Generate(context.Background(), domain.Prompt{})
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || blocked.Prompt || blocked.Reason != "RECITATION" {
+		t.Fatalf("expected a blocked reply, got %v", err)
+	}
+}
+
+func TestGenerateHTTPErrorSurfaces(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":{"code":400,"message":"API key not valid","status":"INVALID_ARGUMENT"}}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, Model: "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.Generate(context.Background(), domain.Prompt{})
+	if err == nil || !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "API key not valid") {
+		t.Fatalf("expected 400 with the API message, got %v", err)
+	}
+}
+
+func TestGenerateTransportErrorHidesQueryKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, APIKey: "secret-key", APIKeyInQuery: true, Model: "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.Generate(context.Background(), domain.Prompt{})
+	if err == nil || strings.Contains(err.Error(), "secret-key") {
+		t.Fatalf("expected a transport error without the key, got %v", err)
+	}
+}
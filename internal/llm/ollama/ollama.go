@@ -0,0 +1,224 @@
+// Package ollama adapts Ollama's native /api/chat endpoint to the
+// app.Generator port. Unlike Ollama's OpenAI-compatible shim it can set the
+// context length and keep-alive, and it knows when a model is not pulled.
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// DefaultBaseURL is where a local Ollama listens.
+const DefaultBaseURL = "http://localhost:11434"
+
+type Config struct {
+	// BaseURL is optional; DefaultBaseURL is used when empty.
+	BaseURL string
+	Model   string
+	// NumCtx is the context length in tokens; zero keeps the model's
+	// default.
+	NumCtx int
+	// KeepAlive is how long the model stays loaded after a request: a
+	// duration ("10m") or a number of seconds ("-1" keeps it loaded);
+	// empty keeps the server's default.
+	KeepAlive string
+}
+
+// ModelNotFoundError reports that the model is not pulled on the server.
+type ModelNotFoundError struct {
+	Model string
+}
+
+func (e *ModelNotFoundError) Error() string {
+	return fmt.Sprintf("ollama model %q is not pulled; run: ollama pull %s",
+		e.Model, e.Model)
+}
+
+type Client struct {
+	http      *http.Client
+	baseURL   string
+	model     domain.ModelID
+	numCtx    int
+	keepAlive any
+}
+
+func New(cfg Config) (*Client, error) {
+	model, err := domain.NewModelID(cfg.Model)
+	if err != nil {
+		return nil, fmt.Errorf("ollama backend: %w", err)
+	}
+	if cfg.NumCtx < 0 {
+		return nil, fmt.Errorf(
+			"ollama backend: num_ctx must not be negative, got %d", cfg.NumCtx)
+	}
+	keepAlive, err := parseKeepAlive(cfg.KeepAlive)
+	if err != nil {
+		return nil, fmt.Errorf("ollama backend: %w", err)
+	}
+	return &Client{
+		http:      http.DefaultClient,
+		baseURL:   serverURL(cfg.BaseURL),
+		model:     model,
+		numCtx:    cfg.NumCtx,
+		keepAlive: keepAlive,
+	}, nil
+}
+
+// parseKeepAlive returns keep_alive as the server reads it: a bare number
+// of seconds goes out as a JSON number, since Ollama parses strings as
+// durations only.
+func parseKeepAlive(s string) (any, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	if _, err := time.ParseDuration(s); err != nil {
+		return nil, fmt.Errorf("keep_alive: want a duration such as 30m "+
+			"or a number of seconds, got %q", s)
+	}
+	return s, nil
+}
+
+type chatRequest struct {
+	Model     string         `json:"model"`
+	Messages  []message      `json:"messages"`
+	Stream    bool           `json:"stream"`
+	KeepAlive any            `json:"keep_alive,omitempty"`
+	Options   map[string]any `json:"options,omitempty"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatResponse struct {
+	Message message `json:"message"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (c *Client) Generate(ctx context.Context, prompt domain.Prompt) (string, error) {
+	req := chatRequest{
+		Model: c.model.String(),
+		Messages: []message{
+			{Role: "system", Content: prompt.System},
+			{Role: "user", Content: prompt.User},
+		},
+		KeepAlive: c.keepAlive,
+	}
+	if c.numCtx > 0 {
+		req.Options = map[string]any{"num_ctx": c.numCtx}
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	raw, status, err := do(ctx, c.http, http.MethodPost,
+		c.baseURL+"/api/chat", body)
+	if err != nil {
+		return "", fmt.Errorf("ollama chat request: %w", err)
+	}
+	if status == http.StatusNotFound &&
+		strings.Contains(errorMessage(raw), "not found") {
+		return "", &ModelNotFoundError{Model: c.model.String()}
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("ollama chat request: %d %s: %s",
+			status, http.StatusText(status), errorMessage(raw))
+	}
+
+	var out chatResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return "", fmt.Errorf("ollama chat response: %w", err)
+	}
+	if out.Message.Content == "" {
+		return "", errors.New("ollama chat response had no content")
+	}
+	return out.Message.Content, nil
+}
+
+// ListModels returns the names of the models pulled on the server at
+// baseURL (DefaultBaseURL when empty), sorted.
+func ListModels(ctx context.Context, baseURL string) ([]string, error) {
+	raw, status, err := do(ctx, http.DefaultClient, http.MethodGet,
+		serverURL(baseURL)+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("listing ollama models: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("listing ollama models: %d %s: %s",
+			status, http.StatusText(status), errorMessage(raw))
+	}
+	var out struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("listing ollama models: %w", err)
+	}
+	names := make([]string, 0, len(out.Models))
+	for _, m := range out.Models {
+		names = append(names, m.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func do(
+	ctx context.Context,
+	client *http.Client,
+	method, url string,
+	body []byte,
+) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url,
+		bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return raw, resp.StatusCode, nil
+}
+
+func serverURL(baseURL string) string {
+	if baseURL == "" {
+		return DefaultBaseURL
+	}
+	return strings.TrimRight(baseURL, "/")
+}
+
+func errorMessage(raw []byte) string {
+	var e errorResponse
+	if json.Unmarshal(raw, &e) == nil && e.Error != "" {
+		return e.Error
+	}
+	return strings.TrimSpace(string(raw))
+}
@@ -0,0 +1,165 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+func TestNewRequiresModel(t *testing.T) {
+	if _, err := New(Config{Model: "  "}); err == nil {
+		t.Fatal("expected error for blank model")
+	}
+}
+
+func TestGenerateSpeaksNativeChat(t *testing.T) {
+	var got struct {
+		chatRequest
+		Options struct {
+			NumCtx int `json:"num_ctx"`
+		} `json:"options"`
+	}
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"model":"llama3.1:8b","message":{"role":"assistant","content":"feat: one\nfix: two"},"done":true}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, Model: "llama3.1:8b", NumCtx: 16384, KeepAlive: "10m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, err := client.Generate(context.Background(), domain.Prompt{System: "sys", User: "user"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "feat: one\nfix: two" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	if gotPath != "/api/chat" {
+		t.Fatalf("unexpected path: %q", gotPath)
+	}
+	if got.Model != "llama3.1:8b" || got.Stream || got.KeepAlive != "10m" || got.Options.NumCtx != 16384 {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != "system" || got.Messages[0].Content != "sys" ||
+		got.Messages[1].Role != "user" || got.Messages[1].Content != "user" {
+		t.Fatalf("unexpected messages: %+v", got.Messages)
+	}
+}
+
+func TestGenerateOmitsUnsetOptions(t *testing.T) {
+	var raw map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&raw)
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"ok"}}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, Model: "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Generate(context.Background(), domain.Prompt{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := raw["options"]; ok {
+		t.Fatalf("options sent without num_ctx: %v", raw)
+	}
+	if _, ok := raw["keep_alive"]; ok {
+		t.Fatalf("keep_alive sent without configuration: %v", raw)
+	}
+}
+
+func TestKeepAliveSecondsSentAsNumber(t *testing.T) {
+	var raw map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&raw)
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"ok"}}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, Model: "m", KeepAlive: "-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Generate(context.Background(), domain.Prompt{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if raw["keep_alive"] != float64(-1) {
+		t.Fatalf("keep_alive = %#v, want the number -1", raw["keep_alive"])
+	}
+
+	if _, err := New(Config{Model: "m", KeepAlive: "forever"}); err == nil {
+		t.Fatal("expected an invalid keep_alive to be rejected")
+	}
+}
+
+func TestGenerateMissingModelHintsPull(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"model \"qwen2.5-coder:7b\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, Model: "qwen2.5-coder:7b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.Generate(context.Background(), domain.Prompt{})
+	var missing *ModelNotFoundError
+	if !errors.As(err, &missing) || missing.Model != "qwen2.5-coder:7b" {
+		t.Fatalf("expected ModelNotFoundError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "ollama pull qwen2.5-coder:7b") {
+		t.Fatalf("error should carry the pull command: %v", err)
+	}
+}
+
+func TestGenerateHTTPErrorSurfaces(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error":"out of memory"}`, http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := New(Config{BaseURL: server.URL, Model: "m"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = client.Generate(context.Background(), domain.Prompt{})
+	if err == nil || !strings.Contains(err.Error(), "500") || !strings.Contains(err.Error(), "out of memory") {
+		t.Fatalf("expected 500 with the server message, got %v", err)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"models":[{"name":"qwen2.5-coder:7b"},{"name":"llama3.1:8b"}]}`))
+	}))
+	defer server.Close()
+
+	got, err := ListModels(context.Background(), server.URL+"/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"llama3.1:8b", "qwen2.5-coder:7b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
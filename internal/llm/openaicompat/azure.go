@@ -0,0 +1,61 @@
+package openaicompat
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI data-plane API version used
+// when none is configured.
+const DefaultAzureAPIVersion = "2024-10-21"
+
+type AzureConfig struct {
+	// Endpoint is the resource URL, https://<resource>.openai.azure.com, or
+	// a gateway in front of it.
+	Endpoint string
+	// Deployment names the model deployment; requests go to its URL and it
+	// stands in for the model name.
+	Deployment string
+	// APIVersion is optional; DefaultAzureAPIVersion is used when empty.
+	APIVersion string
+	APIKey     string
+}
+
+// NewAzure builds a client for an Azure OpenAI deployment: chat completions
+// are sent to <endpoint>/openai/deployments/<deployment>/chat/completions
+// with the api-version query parameter, and the key travels in the api-key
+// header instead of bearer auth.
+func NewAzure(cfg AzureConfig) (*Client, error) {
+	deployment, err := domain.NewModelID(cfg.Deployment)
+	if err != nil {
+		return nil, fmt.Errorf("azure-openai backend: %w", err)
+	}
+	endpoint := strings.TrimRight(strings.TrimSpace(cfg.Endpoint), "/")
+	if endpoint == "" {
+		return nil, errors.New("azure-openai backend: base_url must be " +
+			"the resource endpoint, e.g. https://<resource>.openai.azure.com")
+	}
+	version := cfg.APIVersion
+	if version == "" {
+		version = DefaultAzureAPIVersion
+	}
+
+	opts := []option.RequestOption{
+		option.WithBaseURL(endpoint + "/openai/deployments/" +
+			url.PathEscape(deployment.String()) + "/"),
+		option.WithQuery("api-version", version),
+		// OPENAI_API_KEY in the environment would otherwise add bearer auth.
+		option.WithHeaderDel("authorization"),
+	}
+	if cfg.APIKey != "" {
+		opts = append(opts, option.WithHeader("api-key", cfg.APIKey))
+	}
+	return &Client{api: openai.NewClient(opts...), model: deployment}, nil
+}
@@ -0,0 +1,84 @@
+package openaicompat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m7medvision/lazycommit/internal/domain"
+)
+
+func TestNewAzureRequiresEndpointAndDeployment(t *testing.T) {
+	if _, err := NewAzure(AzureConfig{Endpoint: "https://x.openai.azure.com"}); err == nil {
+		t.Fatal("expected error for blank deployment")
+	}
+	if _, err := NewAzure(AzureConfig{Deployment: "gpt-4o"}); err == nil {
+		t.Fatal("expected error for blank endpoint")
+	}
+}
+
+func TestAzureUsesDeploymentURL(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-from-env")
+
+	var got chatRequest
+	var gotPath, gotVersion, gotKey, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		gotKey = r.Header.Get("api-key")
+		gotAuth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("bad request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"feat: one"}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewAzure(AzureConfig{Endpoint: server.URL + "/", Deployment: "prod-gpt4o", APIKey: "azure-key"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := client.Generate(context.Background(), domain.Prompt{System: "sys", User: "user"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "feat: one" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	if gotPath != "/openai/deployments/prod-gpt4o/chat/completions" {
+		t.Fatalf("unexpected path: %q", gotPath)
+	}
+	if gotVersion != DefaultAzureAPIVersion {
+		t.Fatalf("api-version = %q", gotVersion)
+	}
+	if gotKey != "azure-key" || gotAuth != "" {
+		t.Fatalf("expected api-key header only: api-key=%q Authorization=%q", gotKey, gotAuth)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Content != "sys" || got.Messages[1].Content != "user" {
+		t.Fatalf("unexpected messages: %+v", got.Messages)
+	}
+}
+
+func TestAzureHonorsAPIVersion(t *testing.T) {
+	var gotVersion string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotVersion = r.URL.Query().Get("api-version")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewAzure(AzureConfig{Endpoint: server.URL, Deployment: "d", APIVersion: "2025-01-01-preview"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Generate(context.Background(), domain.Prompt{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotVersion != "2025-01-01-preview" {
+		t.Fatalf("api-version = %q", gotVersion)
+	}
+}
@@ -1,6 +1,6 @@
 // Package openaicompat adapts any OpenAI-compatible chat-completions
 // endpoint (OpenAI, Ollama, OpenRouter, LM Studio, proxies) to the
-// app.Generator port. It is the only direct-API backend.
+// app.Generator port, including Azure OpenAI deployments via NewAzure.
 package openaicompat
 
 import (
@@ -14,9 +14,15 @@ import (
 // BackendConfig carries everything a factory may need to build a backend for
 // one specific model. Fields irrelevant to a given backend are ignored by it.
 type BackendConfig struct {
-	Model   string
-	APIKey  string
-	BaseURL string
+	Model         string
+	APIKey        string
+	BaseURL       string
+	APIKeyInQuery bool
+	APIVersion    string
+	Command       []string
+	InputFormat   string
+	NumCtx        int
+	KeepAlive     string
 }
 
 // Factory builds a Generator from its configuration.
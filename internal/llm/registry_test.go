@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -39,11 +40,11 @@ func TestRegistryPassesConfigToFactory(t *testing.T) {
 		return nullGenerator{}, nil
 	})
 
-	cfg := BackendConfig{Model: "m1", APIKey: "k", BaseURL: "http://localhost"}
+	cfg := BackendConfig{Model: "m1", APIKey: "k", BaseURL: "http://localhost", Command: []string{"llm", "-m", "{model}"}}
 	if _, err := r.New("x", cfg); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if got != cfg {
+	if !reflect.DeepEqual(got, cfg) {
 		t.Fatalf("factory got %+v, want %+v", got, cfg)
 	}
 }
@@ -0,0 +1,379 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	help            = "↑/↓ move · enter choose · e edit · r regenerate with a hint · m more · q quit"
+	maxPreviewLines = 10
+)
+
+// Picker lists suggestions and lets the user move through them, edit the
+// selected one's first line in place, regenerate the list with a hint, or
+// ask for more. Multi-line items show their first line in the list and the
+// rest as a preview under it.
+type Picker struct {
+	Title string
+	Items []string
+	// Width truncates drawn lines; zero means no limit.
+	Width int
+	// Regenerate replaces the list, guided by hint; nil disables it.
+	Regenerate func(hint string) ([]string, error)
+	// More returns additional items, given the current ones; nil disables it.
+	More func(current []string) ([]string, error)
+}
+
+// Run draws the picker on out and handles keys read from in until an item
+// is chosen (ok is true) or the user quits. The drawing is erased before
+// returning.
+func (p *Picker) Run(in io.Reader, out io.Writer) (choice string, ok bool, err error) {
+	if len(p.Items) == 0 {
+		return "", false, errors.New("nothing to pick from")
+	}
+	s := &pickerState{p: p, items: slices.Clone(p.Items), out: out}
+	_, _ = io.WriteString(out, "\x1b[?25l")
+	defer func() {
+		s.erase()
+		_, _ = io.WriteString(out, "\x1b[?25h")
+	}()
+
+	buf := make([]byte, 256)
+	for {
+		s.render()
+		n, readErr := in.Read(buf)
+		for _, k := range decodeKeys(buf[:n]) {
+			if s.handle(k) {
+				return s.choice, s.ok, nil
+			}
+		}
+		if readErr == io.EOF {
+			return "", false, nil
+		}
+		if readErr != nil {
+			return "", false, readErr
+		}
+	}
+}
+
+type mode int
+
+const (
+	modeList mode = iota
+	modeEdit
+	modeHint
+)
+
+type pickerState struct {
+	p      *Picker
+	out    io.Writer
+	items  []string
+	cursor int
+	mode   mode
+	input  []rune
+	pos    int
+	status string
+	drawn  int
+
+	choice string
+	ok     bool
+}
+
+// handle applies one key and reports whether the picker is finished.
+func (s *pickerState) handle(k key) bool {
+	if k.kind == keyInterrupt {
+		return true
+	}
+	if s.mode != modeList {
+		return s.handleInput(k)
+	}
+
+	switch {
+	case k.kind == keyUp || k.is('k'):
+		s.cursor = max(s.cursor-1, 0)
+	case k.kind == keyDown || k.is('j'):
+		s.cursor = min(s.cursor+1, len(s.items)-1)
+	case k.kind == keyEnter:
+		s.choice, s.ok = s.items[s.cursor], true
+		return true
+	case k.kind == keyEsc || k.is('q'):
+		return true
+	case k.is('e'):
+		subject, _, _ := strings.Cut(s.items[s.cursor], "\n")
+		s.startInput(modeEdit, subject)
+	case k.is('r') && s.p.Regenerate != nil:
+		s.startInput(modeHint, "")
+	case k.is('m') && s.p.More != nil:
+		s.generate(func() error {
+			more, err := s.p.More(slices.Clone(s.items))
+			for _, item := range more {
+				if !slices.Contains(s.items, item) {
+					s.items = append(s.items, item)
+				}
+			}
+			return err
+		})
+	case k.kind == keyRune && k.r >= '1' && k.r <= '9':
+		if i := int(k.r - '1'); i < len(s.items) {
+			s.cursor = i
+		}
+	}
+	return false
+}
+
+func (s *pickerState) handleInput(k key) bool {
+	switch k.kind {
+	case keyRune:
+		s.input = slices.Insert(s.input, s.pos, k.r)
+		s.pos++
+	case keyBackspace:
+		if s.pos > 0 {
+			s.input = slices.Delete(s.input, s.pos-1, s.pos)
+			s.pos--
+		}
+	case keyLeft:
+		s.pos = max(s.pos-1, 0)
+	case keyRight:
+		s.pos = min(s.pos+1, len(s.input))
+	case keyHome:
+		s.pos = 0
+	case keyEnd:
+		s.pos = len(s.input)
+	case keyEsc:
+		s.mode = modeList
+	case keyEnter:
+		text := strings.TrimSpace(string(s.input))
+		m := s.mode
+		s.mode = modeList
+		switch {
+		case m == modeEdit && text == "":
+			s.status = "The first line cannot be empty."
+		case m == modeEdit:
+			_, body, found := strings.Cut(s.items[s.cursor], "\n")
+			if found {
+				text += "\n" + body
+			}
+			s.items[s.cursor] = text
+		case m == modeHint:
+			s.generate(func() error {
+				items, err := s.p.Regenerate(text)
+				if err == nil && len(items) > 0 {
+					s.items, s.cursor = items, 0
+				}
+				return err
+			})
+		}
+	}
+	return false
+}
+
+func (s *pickerState) startInput(m mode, text string) {
+	s.mode = m
+	s.input = []rune(text)
+	s.pos = len(s.input)
+	s.status = ""
+}
+
+// generate shows a progress line while fn runs, then its error if any.
+func (s *pickerState) generate(fn func() error) {
+	s.status = "Generating…"
+	s.render()
+	s.status = ""
+	if err := fn(); err != nil {
+		s.status = "Error: " + err.Error()
+	}
+}
+
+func (s *pickerState) render() {
+	lines := []string{s.header()}
+	for i, item := range s.items {
+		subject, body, _ := strings.Cut(item, "\n")
+		if body != "" {
+			subject += dim(fmt.Sprintf(" (+%d lines)", strings.Count(body, "\n")+1))
+		}
+		if i == s.cursor {
+			lines = append(lines, "\x1b[1m> "+s.truncate(subject)+"\x1b[0m")
+		} else {
+			lines = append(lines, "  "+s.truncate(subject))
+		}
+	}
+	if _, body, found := strings.Cut(s.items[s.cursor], "\n"); found {
+		preview := strings.Split(strings.TrimLeft(body, "\n"), "\n")
+		if len(preview) > maxPreviewLines {
+			preview = append(preview[:maxPreviewLines], "…")
+		}
+		lines = append(lines, "")
+		for _, l := range preview {
+			lines = append(lines, dim("    "+s.truncate(l)))
+		}
+	}
+	switch s.mode {
+	case modeEdit:
+		lines = append(lines, "Edit: "+s.inputLine())
+	case modeHint:
+		lines = append(lines, "Hint: "+s.inputLine())
+	}
+	if s.status != "" {
+		lines = append(lines, s.status)
+	}
+
+	s.erase()
+	_, _ = io.WriteString(s.out, strings.Join(lines, "\r\n"))
+	s.drawn = len(lines)
+}
+
+// header is the title and key help, cut to the width like the items; only
+// the help is dimmed.
+func (s *pickerState) header() string {
+	line := []rune(s.truncate(s.p.Title + "  " + help))
+	n := min(utf8.RuneCountInString(s.p.Title), len(line))
+	if n == len(line) {
+		return string(line)
+	}
+	return string(line[:n]) + dim(string(line[n:]))
+}
+
+// erase clears everything drawn so far and leaves the cursor where the
+// drawing started.
+func (s *pickerState) erase() {
+	if s.drawn == 0 {
+		return
+	}
+	if s.drawn > 1 {
+		_, _ = fmt.Fprintf(s.out, "\x1b[%dA", s.drawn-1)
+	}
+	_, _ = io.WriteString(s.out, "\r\x1b[J")
+	s.drawn = 0
+}
+
+// inputLine renders the input with the cursor shown in reverse video.
+func (s *pickerState) inputLine() string {
+	before := string(s.input[:s.pos])
+	at, after := " ", ""
+	if s.pos < len(s.input) {
+		at, after = string(s.input[s.pos]), string(s.input[s.pos+1:])
+	}
+	return before + "\x1b[7m" + at + "\x1b[0m" + after
+}
+
+func (s *pickerState) truncate(line string) string {
+	limit := s.p.Width - 4
+	if s.p.Width <= 0 || utf8.RuneCountInString(line) <= limit {
+		return line
+	}
+	return string([]rune(line)[:max(limit-1, 0)]) + "…"
+}
+
+func dim(s string) string {
+	return "\x1b[2m" + s + "\x1b[0m"
+}
+
+type keyKind int
+
+const (
+	keyRune keyKind = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyEnter
+	keyBackspace
+	keyEsc
+	keyInterrupt
+)
+
+type key struct {
+	kind keyKind
+	r    rune
+}
+
+func (k key) is(r rune) bool {
+	return k.kind == keyRune && k.r == r
+}
+
+// decodeKeys splits one read from a raw-mode terminal into key presses.
+// Terminals write an escape sequence in one go, so an ESC at the end of a
+// read is the Escape key itself. Unknown sequences are skipped whole.
+func decodeKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		c := b[0]
+		switch {
+		case c == 0x1b && len(b) >= 3 && b[1] == 'O':
+			if k, ok := escapeKeys[b[2]]; ok {
+				keys = append(keys, key{kind: k})
+			}
+			b = b[3:]
+			continue
+		case c == 0x1b && len(b) >= 3 && b[1] == '[':
+			// A CSI sequence runs through parameter and intermediate
+			// bytes to a final byte in 0x40-0x7e, as in ESC[3~ or ESC[1;5A.
+			end := 2
+			for end < len(b) && b[end] >= 0x20 && b[end] <= 0x3f {
+				end++
+			}
+			if end < len(b) && b[end] >= 0x40 && b[end] <= 0x7e {
+				if k, ok := csiKey(string(b[2:end]), b[end]); ok {
+					keys = append(keys, key{kind: k})
+				}
+				end++
+			}
+			b = b[end:]
+			continue
+		case c == 0x1b:
+			keys = append(keys, key{kind: keyEsc})
+		case c == '\r' || c == '\n':
+			keys = append(keys, key{kind: keyEnter})
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, key{kind: keyBackspace})
+		case c == 0x03 || c == 0x04:
+			keys = append(keys, key{kind: keyInterrupt})
+		case c == 0x01:
+			keys = append(keys, key{kind: keyHome})
+		case c == 0x05:
+			keys = append(keys, key{kind: keyEnd})
+		case c >= 0x20:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, key{kind: keyRune, r: r})
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// csiKey names a CSI sequence by its final byte, ignoring modifiers;
+// "~" sequences name the key by their first parameter instead.
+func csiKey(params string, final byte) (keyKind, bool) {
+	if final == '~' {
+		first, _, _ := strings.Cut(params, ";")
+		k, ok := tildeKeys[first]
+		return k, ok
+	}
+	k, ok := escapeKeys[final]
+	return k, ok
+}
+
+var tildeKeys = map[string]keyKind{
+	"1": keyHome,
+	"7": keyHome,
+	"4": keyEnd,
+	"8": keyEnd,
+}
+
+var escapeKeys = map[byte]keyKind{
+	'A': keyUp,
+	'B': keyDown,
+	'C': keyRight,
+	'D': keyLeft,
+	'H': keyHome,
+	'F': keyEnd,
+}
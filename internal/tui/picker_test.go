@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func runPicker(t *testing.T, p *Picker, keys string) (string, bool) {
+	t.Helper()
+	var out bytes.Buffer
+	choice, ok, err := p.Run(strings.NewReader(keys), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return choice, ok
+}
+
+func TestPickerNavigateAndChoose(t *testing.T) {
+	p := &Picker{Title: "Pick", Items: []string{"one", "two", "three"}}
+	if choice, ok := runPicker(t, p, "jj\x1b[A\r"); !ok || choice != "two" {
+		t.Fatalf("choice = %q, %v", choice, ok)
+	}
+	if choice, ok := runPicker(t, p, "3\r"); !ok || choice != "three" {
+		t.Fatalf("digit jump: choice = %q, %v", choice, ok)
+	}
+	if _, ok := runPicker(t, p, "jq"); ok {
+		t.Fatal("q must cancel")
+	}
+	if _, ok := runPicker(t, p, "j"); ok {
+		t.Fatal("end of input must cancel")
+	}
+}
+
+func TestPickerEditKeepsBody(t *testing.T) {
+	p := &Picker{Items: []string{"feat: add login\n\nWhy it matters."}}
+	choice, ok := runPicker(t, p, "e\x7f\x7f\x7f\x7f\x7fsignup\x1b[D\x1b[C!\r\r")
+	if !ok || choice != "feat: add signup!\n\nWhy it matters." {
+		t.Fatalf("choice = %q, %v", choice, ok)
+	}
+	if choice, _ := runPicker(t, p, "eXYZ\x1b\r"); choice != p.Items[0] {
+		t.Fatalf("escape must discard the edit, got %q", choice)
+	}
+}
+
+func TestPickerRegenerateAndMore(t *testing.T) {
+	var hint string
+	var current []string
+	p := &Picker{
+		Items: []string{"old"},
+		Regenerate: func(h string) ([]string, error) {
+			hint = h
+			return []string{"new one", "new two"}, nil
+		},
+		More: func(c []string) ([]string, error) {
+			current = c
+			return []string{"new two", "extra"}, nil
+		},
+	}
+	choice, ok := runPicker(t, p, "rmention the api\rmjj\r")
+	if !ok || choice != "extra" {
+		t.Fatalf("choice = %q, %v", choice, ok)
+	}
+	if hint != "mention the api" {
+		t.Fatalf("hint = %q", hint)
+	}
+	if strings.Join(current, "|") != "new one|new two" {
+		t.Fatalf("More got %q", current)
+	}
+}
+
+func TestPickerTruncatesHeader(t *testing.T) {
+	p := &Picker{Title: "Commit message", Items: []string{"feat: x"}, Width: 30}
+	s := &pickerState{p: p, items: p.Items, out: &bytes.Buffer{}}
+	header := s.header()
+	plain := strings.NewReplacer("\x1b[2m", "", "\x1b[0m", "").Replace(header)
+	if !strings.HasPrefix(plain, "Commit message  ") || !strings.HasSuffix(plain, "…") ||
+		len([]rune(plain)) > p.Width {
+		t.Fatalf("header = %q", header)
+	}
+
+	p.Title = strings.Repeat("x", 40)
+	if plain := s.header(); len([]rune(plain)) > p.Width || strings.Contains(plain, "\x1b") {
+		t.Fatalf("long title = %q", plain)
+	}
+}
+
+func TestDecodeKeysConsumesWholeCSISequences(t *testing.T) {
+	keys := decodeKeys([]byte("\x1b[3~a\x1b[1;5A\x1b[4~\x1b[200~b"))
+	want := []key{{kind: keyRune, r: 'a'}, {kind: keyUp}, {kind: keyEnd}, {kind: keyRune, r: 'b'}}
+	if len(keys) != len(want) {
+		t.Fatalf("keys = %v", keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("key %d = %v, want %v", i, keys[i], want[i])
+		}
+	}
+}
+
+func TestDecodeKeys(t *testing.T) {
+	keys := decodeKeys([]byte("aé\x1b[B\x1b\r\x03"))
+	want := []key{{kind: keyRune, r: 'a'}, {kind: keyRune, r: 'é'}, {kind: keyDown}, {kind: keyEsc}, {kind: keyEnter}, {kind: keyInterrupt}}
+	if len(keys) != len(want) {
+		t.Fatalf("keys = %v", keys)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("key %d = %v, want %v", i, keys[i], want[i])
+		}
+	}
+}
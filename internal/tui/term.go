@@ -0,0 +1,86 @@
+// Package tui is a small terminal picker for suggestions. It depends only
+// on the standard library and stty(1), and is used only when stdout is a
+// terminal.
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// IsTerminal reports whether f is a character device, i.e. a terminal.
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Terminal is the controlling terminal switched to raw mode. It reads key
+// presses and receives the picker's drawing; Close restores the previous
+// mode.
+type Terminal struct {
+	tty   *os.File
+	saved string
+	// Width is the terminal's column count, or 0 when unknown.
+	Width int
+}
+
+// OpenTerminal opens /dev/tty in raw, no-echo mode.
+func OpenTerminal() (*Terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("opening terminal: %w", err)
+	}
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		_ = tty.Close()
+		return nil, err
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		_ = tty.Close()
+		return nil, err
+	}
+	t := &Terminal{tty: tty, saved: strings.TrimSpace(saved)}
+	if size, err := stty(tty, "size"); err == nil {
+		if fields := strings.Fields(size); len(fields) == 2 {
+			t.Width, _ = strconv.Atoi(fields[1])
+		}
+	}
+	return t, nil
+}
+
+func (t *Terminal) Read(p []byte) (int, error) {
+	return t.tty.Read(p)
+}
+
+func (t *Terminal) Write(p []byte) (int, error) {
+	return t.tty.Write(p)
+}
+
+// Close restores the terminal mode saved by OpenTerminal.
+func (t *Terminal) Close() error {
+	_, err := stty(t.tty, t.saved)
+	if cerr := t.tty.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("stty %s: %s", strings.Join(args, " "), msg)
+	}
+	return stdout.String(), nil
+}
@@ -15,7 +15,11 @@ import (
 	"github.com/m7medvision/lazycommit/internal/domain"
 	"github.com/m7medvision/lazycommit/internal/git"
 	"github.com/m7medvision/lazycommit/internal/llm"
+	"github.com/m7medvision/lazycommit/internal/llm/anthropic"
+	"github.com/m7medvision/lazycommit/internal/llm/command"
+	"github.com/m7medvision/lazycommit/internal/llm/gemini"
 	"github.com/m7medvision/lazycommit/internal/llm/middleware"
+	"github.com/m7medvision/lazycommit/internal/llm/ollama"
 	"github.com/m7medvision/lazycommit/internal/llm/openaicompat"
 )
 
@@ -76,8 +80,41 @@ func buildDeps() (cmd.Deps, error) {
 		NewPRUC: func() (*app.GeneratePRTitles, error) {
 			return app.NewGeneratePRTitles(gen, gitCLI, cfgRepo), nil
 		},
+		NewPRDescriptionUC: func() (*app.GeneratePRDescription, error) {
+			return app.NewGeneratePRDescription(gen, gitCLI, cfgRepo), nil
+		},
+		NewCreateCommitUC: func() (*app.CreateCommit, error) {
+			return app.NewCreateCommit(gitCLI), nil
+		},
+		NewRewordUC: func() (*app.RewordCommit, error) {
+			return app.NewRewordCommit(gen, gitCLI, cfgRepo, gitCLI), nil
+		},
+		NewRewriteBranchUC: func() (*app.RewriteBranch, error) {
+			reword := app.NewRewordCommit(gen, gitCLI, cfgRepo, gitCLI)
+			return app.NewRewriteBranch(reword, gitCLI), nil
+		},
+		NewSquashUC: func() (*app.SquashMessage, error) {
+			return app.NewSquashMessage(gen, gitCLI, cfgRepo), nil
+		},
+		NewBranchUC: func() (*app.SuggestBranchNames, error) {
+			return app.NewSuggestBranchNames(gen, gitCLI, cfgRepo, gitCLI), nil
+		},
+		NewChangelogUC: func() (*app.GenerateChangelog, error) {
+			return app.NewGenerateChangelog(gen, gitCLI, cfgRepo, gitCLI), nil
+		},
+		NewBumpUC: func() (*app.RecommendBump, error) {
+			return app.NewRecommendBump(gen, gitCLI, cfgRepo, gitCLI, gitCLI), nil
+		},
+		NewHookUC: func() (*app.ManageHook, error) {
+			return app.NewManageHook(gitCLI), nil
+		},
+		NewPrepareMessageUC: func() (*app.PrepareCommitMessage, error) {
+			commit := app.NewGenerateCommitSuggestions(gen, gitCLI, cfgRepo)
+			return app.NewPrepareCommitMessage(commit, gitCLI), nil
+		},
 		ConfigRepo:   cfgRepo,
 		BackendNames: registry.Names(),
+		ListModels:   listModels,
 		Version:      version,
 	}, nil
 }
@@ -93,9 +130,56 @@ func newRegistry() *llm.Registry {
 			Model:   cfg.Model,
 		})
 	})
+	r.Register("anthropic", func(cfg llm.BackendConfig) (app.Generator, error) {
+		return anthropic.New(anthropic.Config{
+			BaseURL: cfg.BaseURL,
+			APIKey:  cfg.APIKey,
+			Model:   cfg.Model,
+		})
+	})
+	r.Register("azure-openai", func(cfg llm.BackendConfig) (app.Generator, error) {
+		return openaicompat.NewAzure(openaicompat.AzureConfig{
+			Endpoint:   cfg.BaseURL,
+			Deployment: cfg.Model,
+			APIVersion: cfg.APIVersion,
+			APIKey:     cfg.APIKey,
+		})
+	})
+	r.Register("exec", func(cfg llm.BackendConfig) (app.Generator, error) {
+		return command.New(command.Config{
+			Command:     cfg.Command,
+			Model:       cfg.Model,
+			InputFormat: command.InputFormat(cfg.InputFormat),
+		})
+	})
+	r.Register("gemini", func(cfg llm.BackendConfig) (app.Generator, error) {
+		return gemini.New(gemini.Config{
+			BaseURL:       cfg.BaseURL,
+			APIKey:        cfg.APIKey,
+			APIKeyInQuery: cfg.APIKeyInQuery,
+			Model:         cfg.Model,
+		})
+	})
+	r.Register("ollama", func(cfg llm.BackendConfig) (app.Generator, error) {
+		return ollama.New(ollama.Config{
+			BaseURL:   cfg.BaseURL,
+			Model:     cfg.Model,
+			NumCtx:    cfg.NumCtx,
+			KeepAlive: cfg.KeepAlive,
+		})
+	})
 	return r
 }
 
+// listModels offers the models a backend already has for `config set`;
+// backends that cannot list them return none and the name is typed.
+func listModels(ctx context.Context, backend, baseURL string) ([]string, error) {
+	if backend != "ollama" {
+		return nil, nil
+	}
+	return ollama.ListModels(ctx, baseURL)
+}
+
 // buildGenerator assembles the active backend: one generator per configured
 // model, each bounded by timeout and retried, then chained for fallback.
 func buildGenerator(registry *llm.Registry, cfgRepo *config.Repository) (app.Generator, error) {
@@ -113,9 +197,15 @@ func buildGenerator(registry *llm.Registry, cfgRepo *config.Repository) (app.Gen
 	gens := make([]app.Generator, 0, len(models))
 	for _, model := range models {
 		gen, err := registry.New(backends.Active, llm.BackendConfig{
-			Model:   model,
-			APIKey:  settings.APIKey,
-			BaseURL: settings.BaseURL,
+			Model:         model,
+			APIKey:        settings.APIKey,
+			BaseURL:       settings.BaseURL,
+			APIKeyInQuery: settings.APIKeyInQuery,
+			APIVersion:    settings.APIVersion,
+			Command:       settings.Command,
+			InputFormat:   settings.InputFormat,
+			NumCtx:        settings.NumCtx,
+			KeepAlive:     settings.KeepAlive,
 		})
 		if err != nil {
 			return nil, err
@@ -2,11 +2,13 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -116,6 +118,77 @@ func TestCommitEndToEnd(t *testing.T) {
 	}
 }
 
+func TestCommitBodyNullSeparatedOutput(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "feat: add login\n\nUsers asked for it.\n=== next message ===\nfix: guard nil\n\nIt panicked.")
+	writeBackendConfig(t, server.URL)
+	stage(t, "file.txt", "hello\n")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"commit", "--body", "-z"}, &stdout, &stderr, strings.NewReader(""))
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	want := "feat: add login\n\nUsers asked for it.\x00fix: guard nil\n\nIt panicked.\x00"
+	if stdout.String() != want {
+		t.Fatalf("stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestCommitApplyCreatesCommit(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "feat: add login\nfix: handle \"quoted\" input")
+	writeBackendConfig(t, server.URL)
+	stage(t, "file.txt", "hello\n")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"commit", "--apply"}, &stdout, &stderr, strings.NewReader("2\n"))
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), " 2) fix: handle \"quoted\" input") {
+		t.Fatalf("choices not listed: %q", stderr.String())
+	}
+	out, err := exec.Command("git", "log", "-1", "--format=%s").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != `fix: handle "quoted" input` {
+		t.Fatalf("committed %q", got)
+	}
+	if !strings.HasSuffix(stdout.String(), "] fix: handle \"quoted\" input\n") {
+		t.Fatalf("stdout = %q", stdout.String())
+	}
+}
+
+func TestCommitInteractivePrintsPlainWithoutTerminal(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "feat: add login\nfix: handle empty diff")
+	writeBackendConfig(t, server.URL)
+	stage(t, "file.txt", "hello\n")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"commit", "-i"}, &stdout, &stderr, strings.NewReader(""))
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if stdout.String() != "feat: add login\nfix: handle empty diff\n" {
+		t.Fatalf("stdout = %q", stdout.String())
+	}
+}
+
+func TestCommitPickRequiresApply(t *testing.T) {
+	setupEnv(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"commit", "--pick", "1"}, &stdout, &stderr, strings.NewReader("")); code == 0 {
+		t.Fatal("expected non-zero exit")
+	}
+	if !strings.Contains(stderr.String(), "--pick requires --apply") {
+		t.Fatalf("stderr = %q", stderr.String())
+	}
+}
+
 func TestCommitNoStagedChanges(t *testing.T) {
 	setupEnv(t)
 
@@ -129,6 +202,26 @@ func TestCommitNoStagedChanges(t *testing.T) {
 	}
 }
 
+func TestCommitNoStagedChangesIgnoresBackendConfig(t *testing.T) {
+	setupEnv(t)
+	dir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "lazycommit")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("backends: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"commit"}, &stdout, &stderr, strings.NewReader(""))
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "No staged changes to commit.") {
+		t.Fatalf("stdout = %q", stdout.String())
+	}
+}
+
 func TestCommitWithoutModelHintsConfigSet(t *testing.T) {
 	setupEnv(t)
 	stage(t, "file.txt", "hello\n")
@@ -191,6 +284,138 @@ func TestPREndToEnd(t *testing.T) {
 	}
 }
 
+func TestSquashPrintsOnlyTheMessage(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "feat: add the feature\n\n- add feature.txt")
+	writeBackendConfig(t, server.URL)
+
+	stage(t, "base.txt", "base\n")
+	if out, err := exec.Command("git", "commit", "-m", "initial").CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+	if out, err := exec.Command("git", "checkout", "-b", "feature").CombinedOutput(); err != nil {
+		t.Fatalf("git checkout: %v\n%s", err, out)
+	}
+	stage(t, "feature.txt", "feature\n")
+	if out, err := exec.Command("git", "commit", "-m", "wip").CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"squash", "main"}, &stdout, &stderr, strings.NewReader(""))
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if stdout.String() != "feat: add the feature\n\n- add feature.txt\n" {
+		t.Fatalf("stdout = %q", stdout.String())
+	}
+}
+
+func TestPRBodyPrintsMarkdown(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "## Summary\n\nAdds the feature.\n\n## Testing\n\n- manual")
+	writeBackendConfig(t, server.URL)
+
+	stage(t, "base.txt", "base\n")
+	if out, err := exec.Command("git", "commit", "-m", "initial").CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+	if out, err := exec.Command("git", "checkout", "-b", "feature").CombinedOutput(); err != nil {
+		t.Fatalf("git checkout: %v\n%s", err, out)
+	}
+	stage(t, "feature.txt", "feature\n")
+	if out, err := exec.Command("git", "commit", "-m", "feature work").CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, out)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"pr", "main", "--body"}, &stdout, &stderr, strings.NewReader(""))
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if stdout.String() != "## Summary\n\nAdds the feature.\n\n## Testing\n\n- manual\n" {
+		t.Fatalf("stdout = %q", stdout.String())
+	}
+}
+
+func TestBranchCreateSwitchesToPickedName(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "add readme\nwrite docs")
+	writeBackendConfig(t, server.URL)
+	stage(t, "README.md", "hello\n")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"branch", "--prefix", "docs", "--ticket", "DOC-7", "--create", "--pick", "2"}, &stdout, &stderr, strings.NewReader(""))
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	out, err := exec.Command("git", "symbolic-ref", "--short", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "docs/doc-7-write-docs" {
+		t.Fatalf("HEAD = %q", got)
+	}
+}
+
+func TestChangelogPrependsToFile(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "- Adds the feature.")
+	writeBackendConfig(t, server.URL)
+	for _, c := range []struct{ name, msg string }{{"base.txt", "chore: init"}, {"feature.txt", "feat: add feature"}} {
+		stage(t, c.name, c.name+"\n")
+		if out, err := exec.Command("git", "commit", "-m", c.msg).CombinedOutput(); err != nil {
+			t.Fatalf("git commit: %v\n%s", err, out)
+		}
+	}
+	if err := os.WriteFile("CHANGELOG.md", []byte("# Changelog\n\n## [0.1.0] - 2026-01-01\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"changelog", "HEAD~1..HEAD", "--release", "0.2.0", "--date", "2026-10-17", "--prepend", "CHANGELOG.md"}, &stdout, &stderr, strings.NewReader(""))
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	data, err := os.ReadFile("CHANGELOG.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Changelog\n\n## [0.2.0] - 2026-10-17\n\n### Added\n\n- Adds the feature.\n\n## [0.1.0] - 2026-01-01\n"
+	if string(data) != want {
+		t.Fatalf("CHANGELOG.md = %q", data)
+	}
+}
+
+func TestBumpPrintsNextVersionAndTags(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "- Adds the feature.")
+	writeBackendConfig(t, server.URL)
+	git := func(args ...string) string {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	stage(t, "base.txt", "base\n")
+	git("commit", "-m", "chore: init")
+	git("tag", "v0.3.1")
+	stage(t, "feature.txt", "feature\n")
+	git("commit", "-m", "feat: add feature")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"bump", "--tag"}, &stdout, &stderr, strings.NewReader("")); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if stdout.String() != "v0.4.0\n" || !strings.Contains(stderr.String(), "minor  ") {
+		t.Fatalf("stdout = %q, stderr = %q", stdout.String(), stderr.String())
+	}
+	if got := git("tag", "-l", "--format=%(contents:subject)", "v0.4.0"); got != "Release v0.4.0" {
+		t.Fatalf("tag subject = %q", got)
+	}
+}
+
 func TestPRRequiresTargetBranch(t *testing.T) {
 	setupEnv(t)
 
@@ -232,3 +457,271 @@ func TestConfigSetThenGet(t *testing.T) {
 		t.Fatalf("expected masked key ****1234:\n%s", out)
 	}
 }
+
+func TestConfigSetListsOllamaModels(t *testing.T) {
+	setupEnv(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"models":[{"name":"qwen2.5-coder:7b"},{"name":"llama3.1:8b"}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	input := "ollama\n" + server.URL + "\n2\n8192\n30m\n\n"
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"config", "set"}, &stdout, &stderr, strings.NewReader(input))
+	if code != 0 {
+		t.Fatalf("config set failed: %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "1)   llama3.1:8b") {
+		t.Fatalf("pulled models should be listed:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"config", "get"}, &stdout, &stderr, strings.NewReader(""))
+	if code != 0 {
+		t.Fatalf("config get failed: %d, stderr: %s", code, stderr.String())
+	}
+	for _, want := range []string{"backend:  ollama", "model:    qwen2.5-coder:7b", "num_ctx:  8192", "keep_alive: 30m"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("config get output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestConfigSetOllamaKeepAlive(t *testing.T) {
+	setupEnv(t)
+	for _, tc := range []struct {
+		keepAlive string
+		ok        bool
+	}{{"-1", true}, {"300", true}, {"1h", true}, {"forever", false}} {
+		input := "ollama\n\nllama3.1:8b\n0\n" + tc.keepAlive + "\n\n"
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"config", "set"}, &stdout, &stderr, strings.NewReader(input))
+		if (code == 0) != tc.ok {
+			t.Fatalf("keep_alive %q: exit %d, stderr: %s", tc.keepAlive, code, stderr.String())
+		}
+	}
+}
+
+func TestAnthropicBackendFromConfigSet(t *testing.T) {
+	setupEnv(t)
+	var gotPath, gotKey, gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotKey = r.Header.Get("x-api-key")
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		gotModel = body.Model
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"feat: add greeting"}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	// The default backend is always 1, so anthropic sorts to 2.
+	input := "2\nclaude-test\n" + server.URL + "\nant-key\n\n"
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"config", "set"}, &stdout, &stderr, strings.NewReader(input)); code != 0 {
+		t.Fatalf("config set failed: %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "1) * openai-compatible") {
+		t.Fatalf("default backend is not first:\n%s", stdout.String())
+	}
+
+	stage(t, "file.txt", "hello\n")
+	stdout.Reset()
+	if code := run([]string{"commit"}, &stdout, &stderr, strings.NewReader("")); code != 0 {
+		t.Fatalf("commit failed: %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "feat: add greeting") {
+		t.Fatalf("unexpected suggestions: %q", stdout.String())
+	}
+	if gotPath != "/v1/messages" || gotKey != "ant-key" || gotModel != "claude-test" {
+		t.Fatalf("unexpected request: path=%q x-api-key=%q model=%q", gotPath, gotKey, gotModel)
+	}
+}
+
+func TestAzureBackendFromConfigSet(t *testing.T) {
+	setupEnv(t)
+	var gotPath, gotVersion, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		gotKey = r.Header.Get("api-key")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"feat: add greeting"}}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	input := "azure-openai\n" + server.URL + "\nprod-gpt4o\n2024-06-01\nazure-key\n\n"
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"config", "set"}, &stdout, &stderr, strings.NewReader(input)); code != 0 {
+		t.Fatalf("config set failed: %d, stderr: %s", code, stderr.String())
+	}
+
+	stage(t, "file.txt", "hello\n")
+	stdout.Reset()
+	if code := run([]string{"commit"}, &stdout, &stderr, strings.NewReader("")); code != 0 {
+		t.Fatalf("commit failed: %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "feat: add greeting") {
+		t.Fatalf("unexpected suggestions: %q", stdout.String())
+	}
+	if gotPath != "/openai/deployments/prod-gpt4o/chat/completions" || gotVersion != "2024-06-01" || gotKey != "azure-key" {
+		t.Fatalf("unexpected request: path=%q api-version=%q api-key=%q", gotPath, gotVersion, gotKey)
+	}
+}
+
+func TestExecBackendRunsCommand(t *testing.T) {
+	setupEnv(t)
+	dir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "lazycommit")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := "active_backend: exec\n" +
+		"backends:\n" +
+		"  exec:\n" +
+		"    model: tiny\n" +
+		"    command: [sh, -c, 'cat > /dev/null; echo \"feat: add greeting with $0\"', '{model}']\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stage(t, "file.txt", "hello\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"commit"}, &stdout, &stderr, strings.NewReader("")); code != 0 {
+		t.Fatalf("commit failed: %d, stderr: %s", code, stderr.String())
+	}
+	if strings.TrimSpace(stdout.String()) != "feat: add greeting with tiny" {
+		t.Fatalf("unexpected suggestions: %q", stdout.String())
+	}
+}
+
+func TestHookRunFillsOnlyPlainCommits(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "feat: add login\nfix: handle empty diff")
+	writeBackendConfig(t, server.URL)
+	stage(t, "file.txt", "hello\n")
+
+	template := "\n# Please enter the commit message for your changes.\n"
+	msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"hook", "run", msgFile, "message"}, template},
+		{[]string{"hook", "run", msgFile}, "feat: add login\n\n# Other suggestions from lazycommit:\n#\n#   fix: handle empty diff\n" + template},
+	} {
+		if err := os.WriteFile(msgFile, []byte(template), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var stdout, stderr bytes.Buffer
+		if code := run(tc.args, &stdout, &stderr, strings.NewReader("")); code != 0 {
+			t.Fatalf("%v: exit code %d, stderr: %s", tc.args, code, stderr.String())
+		}
+		got, err := os.ReadFile(msgFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Fatalf("%v: message file = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestHookRunUsesCommentChar(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "feat: add login\nfix: handle empty diff")
+	writeBackendConfig(t, server.URL)
+	stage(t, "file.txt", "hello\n")
+	if out, err := exec.Command("git", "config", "core.commentChar", ";").CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v\n%s", err, out)
+	}
+
+	template := "\n; Please enter the commit message for your changes.\n"
+	msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	if err := os.WriteFile(msgFile, []byte(template), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"hook", "run", msgFile}, &stdout, &stderr, strings.NewReader("")); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	got, err := os.ReadFile(msgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "feat: add login\n\n; Other suggestions from lazycommit:\n;\n;   fix: handle empty diff\n" + template
+	if string(got) != want {
+		t.Fatalf("message file = %q, want %q", got, want)
+	}
+}
+
+func TestRewordApplyRewritesOlderCommit(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "feat: add the first file")
+	writeBackendConfig(t, server.URL)
+	for i, name := range []string{"one.txt", "two.txt"} {
+		stage(t, name, name+"\n")
+		if out, err := exec.Command("git", "commit", "-m", "wip "+strconv.Itoa(i)).CombinedOutput(); err != nil {
+			t.Fatalf("git commit: %v\n%s", err, out)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"reword", "HEAD~1", "--apply", "--pick", "1"}, &stdout, &stderr, strings.NewReader(""))
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	out, err := exec.Command("git", "log", "--format=%s").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "wip 1\nfeat: add the first file" {
+		t.Fatalf("log = %q", got)
+	}
+}
+
+func TestRewriteBranchRewritesAfterConfirmation(t *testing.T) {
+	setupEnv(t)
+	server := fakeLLMServer(t, "feat: describe the change")
+	writeBackendConfig(t, server.URL)
+	git := func(args ...string) string {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	stage(t, "base.txt", "base\n")
+	git("commit", "-m", "initial")
+	git("checkout", "-q", "-b", "topic")
+	for i, name := range []string{"one.txt", "two.txt"} {
+		stage(t, name, name+"\n")
+		git("commit", "-m", "wip "+strconv.Itoa(i))
+	}
+	oldHead := git("rev-parse", "HEAD")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"rewrite-branch", "main"}, &stdout, &stderr, strings.NewReader("n\n")); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if git("rev-parse", "HEAD") != oldHead {
+		t.Fatal("declined rewrite changed the branch")
+	}
+
+	stdout.Reset()
+	if code := run([]string{"rewrite-branch", "main"}, &stdout, &stderr, strings.NewReader("y\n")); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if got := git("log", "--format=%s"); got != "feat: describe the change\nfeat: describe the change\ninitial" {
+		t.Fatalf("log = %q", got)
+	}
+	if git("rev-parse", "refs/lazycommit/backup/topic") != oldHead {
+		t.Fatal("backup ref does not point at the old head")
+	}
+	if !strings.Contains(stdout.String(), "wip 0\n    -> feat: describe the change") ||
+		!strings.Contains(stdout.String(), "git reset --keep refs/lazycommit/backup/topic") {
+		t.Fatalf("stdout = %q", stdout.String())
+	}
+}